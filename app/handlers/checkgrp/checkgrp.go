@@ -0,0 +1,166 @@
+// Package checkgrp for health, readiness and status handler functions
+package checkgrp
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// readinessTimeout is the maximum time the readiness probe waits for the
+// database to answer.
+const readinessTimeout = time.Second
+
+// Handlers manages the set of check endpoints.
+type Handlers struct {
+	Build     string
+	BuildTime string
+	StartedOn time.Time
+	Log       *slog.Logger
+	DB        *sqlx.DB
+	Draining  *atomic.Bool
+}
+
+// Health holds the state reported by the liveness and readiness probes.
+type Health struct {
+	// Status of the service
+	// example: up
+	Status string `json:"status"`
+}
+
+// Status holds the build and runtime information of the service.
+type Status struct {
+	// Build version of the binary
+	// example: 0.0.1
+	Version string `json:"version"`
+	// Build time of the binary
+	// example: 01-06-2024 10:00:00 UTC
+	BuildTime string `json:"build_time"`
+	// Time the service started
+	// example: 2021-05-25T00:53:16.535668Z
+	StartedOn time.Time `json:"started_on"`
+	// Uptime of the service in seconds
+	// example: 3600
+	Uptime int64 `json:"uptime_s"`
+	// Database connection pool statistics
+	DB DBStats `json:"db"`
+}
+
+// DBStats holds the database connection pool statistics.
+type DBStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDuration       int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// Liveness returns a 200 as long as the service is able to serve requests.
+//
+// swagger:operation GET /healthz Check CheckLiveness
+//
+// # Liveness probe
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/HealthRes"
+func (h Handlers) Liveness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return api.Respond(ctx, w, []Health{{Status: "up"}}, http.StatusOK)
+}
+
+// Readiness checks that the service can talk to the database and is not
+// shutting down. Traffic should only be routed to the service while this
+// returns a 200.
+//
+// swagger:operation GET /readyz Check CheckReadiness
+//
+// # Readiness probe
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/HealthRes"
+//	  "503":
+//		   "$ref": "#/responses/errorResponse503"
+func (h Handlers) Readiness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	if h.Draining != nil && h.Draining.Load() {
+		er := api.ErrorResponse{
+			Error: "service is shutting down",
+		}
+		return api.Respond(ctx, w, er, http.StatusServiceUnavailable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := database.StatusCheck(ctx, h.DB); err != nil {
+		h.Log.Warn("readiness", "tracer_uid", api.GetTracerUID(ctx), "status", "db not ready", slog.Any("ERROR", err))
+		er := api.ErrorResponse{
+			Error: "database not ready",
+		}
+		return api.Respond(ctx, w, er, http.StatusServiceUnavailable)
+	}
+
+	return api.Respond(ctx, w, []Health{{Status: "ready"}}, http.StatusOK)
+}
+
+// Status returns the build version, build time, uptime and database pool
+// statistics of the service.
+//
+// swagger:operation GET /status Check CheckStatus
+//
+// # Service status
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/StatusRes"
+func (h Handlers) Status(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	s := Status{
+		Version:   h.Build,
+		BuildTime: h.BuildTime,
+		StartedOn: h.StartedOn,
+		Uptime:    int64(time.Since(h.StartedOn).Seconds()),
+		DB:        toDBStats(h.DB.Stats()),
+	}
+
+	return api.Respond(ctx, w, []Status{s}, http.StatusOK)
+}
+
+// =============================================================================
+
+func toDBStats(s sql.DBStats) DBStats {
+	return DBStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
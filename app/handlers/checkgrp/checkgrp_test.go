@@ -0,0 +1,114 @@
+package checkgrp_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/app/handlers/checkgrp"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/api/middleware"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+// newTestAPI serves the check handlers the way the service does, against
+// a database nothing listens for.
+func newTestAPI(t *testing.T, draining *atomic.Bool) *api.API {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := sqlx.Open("mysql", "user:pass@tcp(127.0.0.1:1)/employee?timeout=200ms")
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to open the database : %s", Failed, err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	h := checkgrp.Handlers{
+		Build:     "0.0.1",
+		StartedOn: time.Now().Add(-time.Minute),
+		Log:       log,
+		DB:        db,
+		Draining:  draining,
+	}
+
+	a := api.NewAPI(log, nil, middleware.Errors(log))
+	a.Handle(http.MethodGet, "/healthz", h.Liveness)
+	a.Handle(http.MethodGet, "/readyz", h.Readiness)
+	a.Handle(http.MethodGet, "/v1/status", h.Status)
+
+	return a
+}
+
+func Test_Checks(t *testing.T) {
+	draining := &atomic.Bool{}
+	a := newTestAPI(t, draining)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Log("Given the need to report the state of the service to the orchestrator")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the database cannot be reached.", testID)
+		{
+			if w := get("/healthz"); w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould be alive : %d %s", Failed, testID, w.Code, w.Body)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be alive", Success, testID)
+
+			if w := get("/readyz"); w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be ready : %d %s", Failed, testID, w.Code, w.Body)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be ready", Success, testID)
+
+			w := get("/v1/status")
+			var got struct {
+				Data []checkgrp.Status `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK || len(got.Data) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould report the status : %d %s %v", Failed, testID, w.Code, w.Body, err)
+			}
+			if got.Data[0].Version != "0.0.1" || got.Data[0].Uptime < 60 {
+				t.Fatalf("\t%s\tTest %d:\tShould report the build and uptime : %+v", Failed, testID, got.Data[0])
+			}
+			t.Logf("\t%s\tTest %d:\tShould report the build and uptime", Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen the service is draining.", testID)
+		{
+			draining.Store(true)
+
+			w := get("/readyz")
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be ready : %d %s", Failed, testID, w.Code, w.Body)
+			}
+			var er struct {
+				Errors api.ErrorResponse `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &er); err != nil || er.Errors.Error != "service is shutting down" {
+				t.Fatalf("\t%s\tTest %d:\tShould tell the service is shutting down : %s %v", Failed, testID, w.Body, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be ready", Success, testID)
+
+			if w := get("/healthz"); w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould still be alive : %d %s", Failed, testID, w.Code, w.Body)
+			}
+			t.Logf("\t%s\tTest %d:\tShould still be alive", Success, testID)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/pansachin/employee-service/models/department"
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/models/position"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/auth"
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// errorCodes are the stable codes of the errors clients can meet. Codes are
+// part of the API contract: never change one, add a new error instead. The
+// errors of the models come first as they can wrap database errors.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{employee.ErrNotFound, "employee_not_found"},
+	{employee.ErrInvalidID, "invalid_id"},
+	{employee.ErrInvalidAlias, "invalid_alias"},
+	{employee.ErrInvalidDepartment, "invalid_department"},
+	{employee.ErrInvalidManager, "invalid_manager"},
+	{employee.ErrInvalidPosition, "invalid_position"},
+	{employee.ErrVersionMismatch, "version_mismatch"},
+	{employee.ErrPendingChangeNotFound, "pending_change_not_found"},
+
+	{department.ErrNotFound, "department_not_found"},
+	{department.ErrInvalidID, "invalid_id"},
+	{department.ErrHasEmployees, "department_has_employees"},
+
+	{position.ErrNotFound, "position_not_found"},
+	{position.ErrInvalidID, "invalid_id"},
+	{position.ErrHasEmployees, "position_has_employees"},
+
+	{auth.ErrUnauthenticated, "unauthenticated"},
+	{auth.ErrForbidden, "forbidden"},
+
+	{database.ErrInvalidCursor, "invalid_cursor"},
+	{database.ErrDBNotFound, "not_found"},
+	{database.ErrDBDuplicatedEntry, "duplicated_entry"},
+	{database.ErrDBVersionConflict, "version_conflict"},
+	{database.ErrDBReferenceNotFound, "reference_not_found"},
+	{database.ErrDBStillReferenced, "still_referenced"},
+	{database.ErrDBDataTooLong, "value_too_long"},
+	{database.ErrDBLockConflict, "lock_conflict"},
+	{database.ErrDBUnavailable, "database_unavailable"},
+}
+
+// registerErrorCodes makes the codes known to the API.
+func registerErrorCodes() {
+	for _, ec := range errorCodes {
+		api.RegisterErrorCode(ec.err, ec.code)
+	}
+}
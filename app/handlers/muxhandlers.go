@@ -6,13 +6,17 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
-	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/pansachin/employee-service/app/handlers/checkgrp"
 	v1 "github.com/pansachin/employee-service/app/handlers/v1"
 	"github.com/pansachin/employee-service/pkg/api"
 	"github.com/pansachin/employee-service/pkg/api/middleware"
+	"github.com/pansachin/employee-service/pkg/auth"
+	"github.com/pansachin/employee-service/pkg/metrics"
 )
 
 // Options represent optional parameters.
@@ -22,12 +26,19 @@ type Options struct {
 
 // APIMuxConfig contains all the mandatory systems required by handlers.
 type APIMuxConfig struct {
-	Env      string
-	Shutdown chan os.Signal
-	Log      *slog.Logger
-	DB       *sqlx.DB
-	RWMux    *sync.RWMutex
-	Headers  bool
+	Env            string
+	Build          string
+	BuildTime      string
+	StartedOn      time.Time
+	Shutdown       chan os.Signal
+	Draining       *atomic.Bool
+	Log            *slog.Logger
+	DB             *sqlx.DB
+	Headers        bool
+	RequireIfMatch bool
+	ProblemDetails bool
+	Auth           *auth.Auth
+	Policy         *auth.Policy
 }
 
 // APIMux constructs a http.Handler with all application routes defined.
@@ -40,12 +51,16 @@ func APIMux(cfg APIMuxConfig, options ...func(opts *Options)) http.Handler {
 	// Construct the web.App which holds all routes as well as common Middleware.
 	mw := make([]api.Middleware, 0, 6)
 	mw = append(mw, middleware.Logger(cfg.Log))
+	mw = append(mw, middleware.Metrics())
 	mw = append(mw, middleware.Errors(cfg.Log))
 	mw = append(mw, middleware.Panics())
 	a := api.NewAPI(
+		cfg.Log,
 		cfg.Shutdown,
 		mw...,
 	)
+	a.SetProblemDefault(cfg.ProblemDetails)
+	registerErrorCodes()
 
 	// Accept CORS 'OPTIONS' preflight requests if config has been provided.
 	// Don't forget to apply the CORS middleware to the routes that need it.
@@ -57,12 +72,35 @@ func APIMux(cfg APIMuxConfig, options ...func(opts *Options)) http.Handler {
 		a.Handle(http.MethodOptions, "", h, middleware.Cors(opts.corsOrigin))
 	}
 
+	// Liveness and readiness probes are not versioned.
+	cg := checkgrp.Handlers{
+		Log:      cfg.Log,
+		DB:       cfg.DB,
+		Draining: cfg.Draining,
+	}
+	a.Handle(http.MethodGet, "/healthz", cg.Liveness)
+	a.Handle(http.MethodGet, "/readyz", cg.Readiness)
+
 	// Load the v1 routes.
 	v1.Routes(a, v1.Config{
-		Log:   cfg.Log,
-		DB:    cfg.DB,
-		RWMux: cfg.RWMux,
+		Build:          cfg.Build,
+		BuildTime:      cfg.BuildTime,
+		StartedOn:      cfg.StartedOn,
+		Log:            cfg.Log,
+		DB:             cfg.DB,
+		RequireIfMatch: cfg.RequireIfMatch,
+		Auth:           cfg.Auth,
+		Policy:         cfg.Policy,
 	})
 
 	return a
 }
+
+// AdminMux constructs a http.Handler with the administrative routes which
+// are served on a separate listener from the API.
+func AdminMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	return mux
+}
@@ -36,3 +36,125 @@ type _ struct {
 		Errors map[string]string `json:"errors"`
 	}
 }
+
+// swagger:response errorResponse503
+type _ struct {
+	// in:body
+	Body struct {
+		// Service Unavailable
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "database not ready"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse401
+type _ struct {
+	// in:body
+	Body struct {
+		// Unauthorized
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "authentication required"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse403
+type _ struct {
+	// in:body
+	Body struct {
+		// Forbidden
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "not allowed to perform this action"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse409
+type _ struct {
+	// in:body
+	Body struct {
+		// Conflict
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "department still has active employees"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse412
+type _ struct {
+	// in:body
+	Body struct {
+		// Precondition Failed
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "employee has been modified"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse415
+type _ struct {
+	// in:body
+	Body struct {
+		// Unsupported Media Type
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "content type must be text/csv or application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response errorResponse428
+type _ struct {
+	// in:body
+	Body struct {
+		// Precondition Required
+		//
+		// example: false
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// example: {"error": "If-Match header is required"}
+		Errors map[string]string `json:"errors"`
+	}
+}
+
+// swagger:response notModified304
+type _ struct{}
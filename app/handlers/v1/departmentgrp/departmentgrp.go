@@ -0,0 +1,257 @@
+// Package departmentgrp for department handler functions
+package departmentgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pansachin/employee-service/models/department"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// Handlers manages the set of department endpoints.
+type Handlers struct {
+	Department department.Core
+}
+
+// Create a new department record
+//
+// # Create a new Department record
+//
+// ---
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+//
+//swagger:operation POST /department Department DepartmentCreate
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	nd := department.NewDepartment{}
+	if err := api.Decode(r, &nd); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	data, err := h.Department.Create(ctx, nd, now)
+	if err != nil {
+		return fmt.Errorf("creating department: %w", err)
+	}
+
+	return api.Respond(ctx, w, []department.Department{data}, http.StatusOK)
+}
+
+// Query all the Department records
+//
+// swagger:operation GET /department Department DepartmentQuery
+//
+// # This is the summary for listing Departments
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pagi, err := database.PaginationParams(r)
+	if err != nil {
+		return err
+	}
+
+	rs, err := h.Department.Query(ctx, pagi)
+	if err != nil {
+		return fmt.Errorf("unable to query for Department: %w", err)
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// QueryByID from an individual id
+//
+// swagger:operation GET /department/{id} Department DepartmentQueryById
+//
+// # Getting a single Department by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	rs, err := h.Department.QueryByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, department.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("department id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, []department.Department{rs}, http.StatusOK)
+}
+
+// Delete from an individual id
+//
+// swagger:operation DELETE /department/{id} Department DepartmentDelete
+//
+// # Delete a single Department by ID
+//
+// Departments that still have active employees cannot be deleted.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	now := time.Now().UTC()
+
+	err := h.Department.Delete(ctx, id, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, department.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, department.ErrHasEmployees):
+			return api.NewRequestError(department.ErrHasEmployees, http.StatusConflict)
+		default:
+			return fmt.Errorf("department id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Update from an individual id
+//
+// swagger:operation PATCH /department/{id} Department DepartmentUpdate
+//
+// # Update a single Department by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	ud := department.UpdateDepartment{}
+	if err := api.Decode(r, &ud); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err := h.Department.Update(ctx, id, ud, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, department.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("department id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// UnDelete from an individual id
+//
+// swagger:operation PATCH /department/undelete/{id} Department DepartmentUnDelete
+//
+// # UnDelete a single Department by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/DepartmentRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+func (h Handlers) UnDelete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	now := time.Now().UTC()
+
+	err := h.Department.UnDelete(ctx, id, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("department id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
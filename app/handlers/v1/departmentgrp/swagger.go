@@ -0,0 +1,47 @@
+package departmentgrp
+
+import "github.com/pansachin/employee-service/models/department"
+
+// swagger:response DepartmentRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []department.Department `json:"data"`
+	}
+}
+
+// swagger:parameters DepartmentQueryById DepartmentDelete DepartmentUpdate DepartmentUnDelete
+type _ struct {
+	// Department ID
+	//
+	// in: path
+	// required: true
+	// enum: 1
+	// type: integer
+	ID string `json:"id"`
+}
+
+// swagger:parameters DepartmentCreate
+type _ struct {
+	// The body to create a department
+	// in:body
+	// required: true
+	Body department.NewDepartment
+}
+
+// swagger:parameters DepartmentUpdate
+type _ struct {
+	// The body to update a department
+	// in:body
+	// required: true
+	Body department.UpdateDepartment
+}
@@ -0,0 +1,171 @@
+package employeegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// BatchItem is the outcome of one operation of a batch.
+//
+//swagger:model BatchItem
+type BatchItem struct {
+	// Position of the operation in the batch
+	// example: 0
+	Index int `json:"index"`
+	// HTTP status the operation would have been answered with on its own
+	// example: 200
+	Status int `json:"status"`
+	// The employee created or updated
+	Data *employee.Employee `json:"data,omitempty"`
+	// Why the operation failed
+	// example: employee not found
+	Error string `json:"error,omitempty"`
+	// Field errors of the operation
+	// example: {"name": "name is a required field"}
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Batch of employee operations
+//
+// swagger:operation POST /employee:batch Employee EmployeeBatch
+//
+// # Create, update and delete Employees in one call
+//
+// An atomic batch applies every operation or none and answers 200, the
+// first failing operation is reported like a single call would be. Other
+// batches apply each operation on its own and answer 207 with the status
+// of every operation.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/EmployeeBatchRes"
+//	  "207":
+//		   "$ref": "#/responses/EmployeeBatchRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+//	  "412":
+//		   "$ref": "#/responses/errorResponse412"
+func (h Handlers) Batch(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var b employee.Batch
+	if err := api.Decode(r, &b); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	rs, err := h.Employee.Batch(ctx, b, now)
+	if err != nil {
+		var be *employee.BatchError
+		if !errors.As(err, &be) {
+			return err
+		}
+		// The failing operation is answered like the single call, its
+		// fields named after its place in the batch.
+		switch {
+		case validate.IsFieldErrors(be.Err):
+			return prefixFields(be.Index, validate.GetFieldErrors(be.Err))
+		case database.IsError(be.Err):
+			return err
+		}
+		if status := opStatus(be.Err); status != http.StatusInternalServerError {
+			return api.NewRequestError(err, status)
+		}
+		return fmt.Errorf("batch: %w", err)
+	}
+
+	items := make([]BatchItem, len(rs))
+	for i, res := range rs {
+		items[i] = h.batchItem(ctx, i, res)
+	}
+
+	status := http.StatusOK
+	if !b.Atomic {
+		status = http.StatusMultiStatus
+	}
+
+	return api.Respond(ctx, w, items, status)
+}
+
+// batchItem reports the result of an operation with the status and the
+// error body the single call would have answered with. Unexpected errors
+// are logged and hidden from the client.
+func (h Handlers) batchItem(ctx context.Context, index int, res employee.BatchResult) BatchItem {
+	item := BatchItem{Index: index, Status: http.StatusOK, Data: res.Employee}
+	if res.Err == nil {
+		return item
+	}
+
+	item.Error = res.Err.Error()
+	switch {
+	case validate.IsFieldErrors(res.Err):
+		item.Status = http.StatusBadRequest
+		item.Error = validate.GetCustomError(res.Err)
+		if item.Error == "" {
+			item.Error = "data validation error"
+		}
+		item.Fields = validate.GetFieldErrors(res.Err).Fields()
+	case database.IsError(res.Err):
+		dbErr := database.GetError(res.Err)
+		item.Status = dbErr.Status
+		if dbErr.Field != "" {
+			item.Fields = map[string]string{dbErr.Field: dbErr.Error()}
+		}
+	default:
+		item.Status = opStatus(res.Err)
+		if item.Status == http.StatusInternalServerError {
+			if h.Log != nil {
+				h.Log.Error("batch operation", "tracer_uid", api.GetTracerUID(ctx), "index", index, slog.Any("ERROR", res.Err))
+			}
+			item.Error = http.StatusText(http.StatusInternalServerError)
+		}
+	}
+
+	return item
+}
+
+// opStatus maps the errors of an operation the single calls answer with a
+// plain error message, anything else is unexpected.
+func opStatus(err error) int {
+	switch {
+	case errors.Is(err, employee.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, employee.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, employee.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// prefixFields names the fields of an operation after its place in the
+// batch, e.g. operations[2].name.
+func prefixFields(index int, fe validate.FieldErrors) validate.FieldErrors {
+	res := validate.FieldErrors{CustomError: fe.CustomError}
+	for _, f := range fe.FieldError {
+		res.FieldError = append(res.FieldError, validate.FieldError{
+			Field: fmt.Sprintf("operations[%d].%s", index, f.Field),
+			Error: f.Error,
+		})
+	}
+	return res
+}
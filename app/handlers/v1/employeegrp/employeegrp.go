@@ -5,7 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/pansachin/employee-service/models/employee"
@@ -15,7 +17,12 @@ import (
 
 // Handlers manages the set of employee endpoints.
 type Handlers struct {
+	Log      *slog.Logger
 	Employee employee.Core
+
+	// RequireIfMatch refuses updates and deletes without an If-Match
+	// header, so clients cannot overwrite changes they have not seen.
+	RequireIfMatch bool
 }
 
 // Create a new employee record
@@ -30,6 +37,10 @@ type Handlers struct {
 //		   "$ref": "#/responses/EmployeeRes"
 //	  "400":
 //		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
 //
@@ -37,7 +48,7 @@ type Handlers struct {
 func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	nes := employee.NewEmployee{}
 	if err := api.Decode(r, &nes); err != nil {
-		return api.NewRequestError(err, http.StatusBadRequest)
+		return err
 	}
 
 	now := time.Now().UTC()
@@ -69,7 +80,13 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 // responses:
 //
 //	  "200":
-//		   "$ref": "#/responses/EmployeeRes"
+//		   "$ref": "#/responses/EmployeeListRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
 func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -78,9 +95,29 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return err
 	}
 
-	rs, err := h.Employee.Query(ctx, pagi)
+	filter, err := filterParams(r)
+	if err != nil {
+		return err
+	}
+
+	count, err := countParam(r)
+	if err != nil {
+		return err
+	}
+
+	asOf, err := asOfParam(r)
+	if err != nil {
+		return err
+	}
+	if asOf != nil {
+		return h.queryAsOf(ctx, w, r, filter, pagi, *asOf)
+	}
+
+	rs, cursors, err := h.Employee.Query(ctx, filter, pagi)
 	if err != nil {
 		switch {
+		case errors.Is(err, employee.ErrInvalidDepartment):
+			return api.NewRequestError(err, http.StatusBadRequest)
 		case errors.Is(err, employee.ErrNotFound):
 			return api.NewRequestError(err, http.StatusNotFound)
 		default:
@@ -88,7 +125,42 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	return api.Respond(ctx, w, rs, http.StatusOK)
+	page := api.Page{
+		Data:       rs,
+		NextCursor: cursors.Next,
+		PrevCursor: cursors.Prev,
+	}
+
+	if count {
+		total, err := h.Employee.Count(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("unable to count Employee: %w", err)
+		}
+		page.Meta = database.NewPaginationResults(pagi, total)
+	}
+
+	return api.RespondPage(ctx, w, r, page, http.StatusOK)
+}
+
+// queryAsOf lists the employees as they were at the instant. The total
+// comes with the listing so it is always returned.
+func (h Handlers) queryAsOf(ctx context.Context, w http.ResponseWriter, r *http.Request, filter employee.QueryFilter, pagi database.Pagination, asOf time.Time) error {
+	rs, total, err := h.Employee.QueryAsOf(ctx, filter, pagi, asOf)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidDepartment):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("unable to query for Employee as of %s: %w", asOf.Format(time.RFC3339), err)
+		}
+	}
+
+	page := api.Page{
+		Data: rs,
+		Meta: database.NewPaginationResults(pagi, total),
+	}
+
+	return api.RespondPage(ctx, w, r, page, http.StatusOK)
 }
 
 // QueryByID from an individual id
@@ -104,11 +176,38 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 //
 //	  "200":
 //		   "$ref": "#/responses/EmployeeRes"
+//	  "304":
+//		   "$ref": "#/responses/notModified304"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
 func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	id := api.Param(r, "id")
 
+	asOf, err := asOfParam(r)
+	if err != nil {
+		return err
+	}
+
+	// Past states carry no ETag, they cannot be the base of a change.
+	if asOf != nil {
+		rs, err := h.Employee.QueryByIDAsOf(ctx, id, *asOf)
+		if err != nil {
+			switch {
+			case errors.Is(err, employee.ErrInvalidID):
+				return api.NewRequestError(err, http.StatusBadRequest)
+			case errors.Is(err, employee.ErrNotFound):
+				return api.NewRequestError(err, http.StatusNotFound)
+			default:
+				return fmt.Errorf("employee id[%s]: %w", id, err)
+			}
+		}
+		return api.Respond(ctx, w, []employee.Employee{rs}, http.StatusOK)
+	}
+
 	rs, err := h.Employee.QueryByID(ctx, id)
 	if err != nil {
 		switch {
@@ -121,6 +220,12 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 		}
 	}
 
+	tag := rs.ETag()
+	w.Header().Set("ETag", tag)
+	if api.IfNoneMatch(r, tag) {
+		return api.Respond(ctx, w, nil, http.StatusNotModified)
+	}
+
 	return api.Respond(ctx, w, []employee.Employee{rs}, http.StatusOK)
 }
 
@@ -137,20 +242,37 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 //
 //	  "200":
 //		   "$ref": "#/responses/EmployeeRes"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
+//	  "412":
+//		   "$ref": "#/responses/errorResponse412"
+//	  "428":
+//		   "$ref": "#/responses/errorResponse428"
 func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	id := api.Param(r, "id")
 
+	match, err := h.ifMatch(r)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 
-	err := h.Employee.Delete(ctx, id, now)
+	err = h.Employee.Delete(ctx, id, match, now)
 	if err != nil {
 		switch {
 		case errors.Is(err, employee.ErrInvalidID):
 			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound) && match != nil:
+			return api.NewRequestError(err, http.StatusPreconditionFailed)
 		case errors.Is(err, employee.ErrNotFound):
 			return api.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, employee.ErrVersionMismatch):
+			return api.NewRequestError(err, http.StatusPreconditionFailed)
 		default:
 			return fmt.Errorf("employee id[%s]: %w", id, err)
 		}
@@ -172,30 +294,68 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 //
 //	  "200":
 //		   "$ref": "#/responses/EmployeeRes"
+//	  "202":
+//		   "$ref": "#/responses/PendingChangeRes"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
+//	  "412":
+//		   "$ref": "#/responses/errorResponse412"
+//	  "428":
+//		   "$ref": "#/responses/errorResponse428"
 func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	id := api.Param(r, "id")
 
 	ues := employee.UpdateEmployee{}
 	if err := api.Decode(r, &ues); err != nil {
-		return api.NewRequestError(err, http.StatusBadRequest)
+		return err
 	}
 
 	now := time.Now().UTC()
 
-	err := h.Employee.Update(ctx, id, ues, now)
+	// A change taking effect later is only recorded, the version it will
+	// apply to is not known yet so If-Match does not apply.
+	if ues.EffectiveOn != nil && ues.EffectiveOn.After(now) {
+		pc, err := h.Employee.Schedule(ctx, id, ues, now)
+		if err != nil {
+			switch {
+			case errors.Is(err, employee.ErrInvalidID):
+				return api.NewRequestError(err, http.StatusBadRequest)
+			case errors.Is(err, employee.ErrNotFound):
+				return api.NewRequestError(err, http.StatusNotFound)
+			default:
+				return fmt.Errorf("employee id[%s]: %w", id, err)
+			}
+		}
+		return api.Respond(ctx, w, []employee.PendingChange{pc}, http.StatusAccepted)
+	}
+
+	match, err := h.ifMatch(r)
+	if err != nil {
+		return err
+	}
+
+	rs, err := h.Employee.Update(ctx, id, ues, match, now)
 	if err != nil {
 		switch {
 		case errors.Is(err, employee.ErrInvalidID):
 			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound) && match != nil:
+			return api.NewRequestError(err, http.StatusPreconditionFailed)
 		case errors.Is(err, employee.ErrNotFound):
 			return api.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, employee.ErrVersionMismatch):
+			return api.NewRequestError(err, http.StatusPreconditionFailed)
 		default:
 			return fmt.Errorf("employee id[%s]: %w", id, err)
 		}
 	}
 
+	w.Header().Set("ETag", rs.ETag())
+
 	return api.Respond(ctx, w, nil, http.StatusOK)
 }
 
@@ -212,6 +372,10 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 //
 //	  "200":
 //		   "$ref": "#/responses/EmployeeRes"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
 //	  "404":
 //		   "$ref": "#/responses/errorResponse404"
 func (h Handlers) UnDelete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -233,3 +397,290 @@ func (h Handlers) UnDelete(ctx context.Context, w http.ResponseWriter, r *http.R
 
 	return api.Respond(ctx, w, nil, http.StatusOK)
 }
+
+// Reports of an individual id
+//
+// swagger:operation GET /employee/{id}/reports Employee EmployeeReports
+//
+// # List the employees reporting to an Employee
+//
+// The direct reports by default, `depth` includes the transitive reports
+// down to that many levels, ordered by level.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/EmployeeRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) Reports(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	depth, err := depthParam(r, 1)
+	if err != nil {
+		return err
+	}
+
+	rs, err := h.Employee.Reports(ctx, id, depth)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID), errors.Is(err, employee.ErrInvalidDepth):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("employee id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// Chain of an individual id
+//
+// swagger:operation GET /employee/{id}/chain Employee EmployeeChain
+//
+// # List the management chain of an Employee
+//
+// Starts with the direct manager and ends with the root of the organization.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/EmployeeRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) Chain(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	rs, err := h.Employee.Chain(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("employee id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// History of an individual id
+//
+// swagger:operation GET /employee/{id}/history Employee EmployeeHistory
+//
+// # List the changes made to an Employee
+//
+// Every create, update, delete and undelete with the caller, the trace ID
+// and the fields changed, newest first by default.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/EmployeeHistoryRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) History(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	pagi, err := database.PaginationParams(r)
+	if err != nil {
+		return err
+	}
+	// The history is always in the order the changes were made.
+	pagi.Sort = "created_on"
+
+	rs, total, err := h.Employee.History(ctx, id, pagi)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("employee id[%s]: %w", id, err)
+		}
+	}
+
+	page := api.Page{
+		Data: rs,
+		Meta: database.NewPaginationResults(pagi, total),
+	}
+
+	return api.RespondPage(ctx, w, r, page, http.StatusOK)
+}
+
+// PendingChanges of an individual id
+//
+// swagger:operation GET /employee/{id}/pending-changes Employee EmployeePendingChanges
+//
+// # List the changes of an Employee waiting for their effective date
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PendingChangeRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) PendingChanges(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	rs, err := h.Employee.PendingChanges(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("employee id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// CancelPendingChange of an individual id
+//
+// swagger:operation DELETE /employee/{id}/pending-changes/{change_id} Employee EmployeeCancelPendingChange
+//
+// # Cancel a change of an Employee before its effective date
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PendingChangeRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) CancelPendingChange(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+	changeID := api.Param(r, "change_id")
+
+	now := time.Now().UTC()
+
+	err := h.Employee.CancelPendingChange(ctx, id, changeID, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound),
+			errors.Is(err, employee.ErrPendingChangeNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("employee id[%s] pending change id[%s]: %w", id, changeID, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// OrgChart of the organization
+//
+// swagger:operation GET /orgchart Employee OrgChart
+//
+// # The org chart as a nested tree
+//
+// Every employee without a manager is the root of a tree, `root` returns
+// only the tree below one employee and `depth` limits the levels below
+// the roots.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/OrgChartRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) OrgChart(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	root := r.URL.Query().Get("root")
+
+	depth, err := depthParam(r, employee.MaxDepth)
+	if err != nil {
+		return err
+	}
+
+	rs, err := h.Employee.OrgChart(ctx, root, depth)
+	if err != nil {
+		switch {
+		case errors.Is(err, employee.ErrInvalidID), errors.Is(err, employee.ErrInvalidDepth):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, employee.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("org chart: %w", err)
+		}
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// depthParam reads the depth query parameter, falling back to def.
+func depthParam(r *http.Request, def int) (int, error) {
+	val := r.URL.Query().Get("depth")
+	if val == "" {
+		return def, nil
+	}
+
+	depth, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, api.NewRequestError(fmt.Errorf("invalid depth format: %s", val), http.StatusBadRequest)
+	}
+
+	return depth, nil
+}
@@ -0,0 +1,136 @@
+package employeegrp_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/app/handlers/v1/employeegrp"
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/api/middleware"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+// unavailableDB is a database no connection can be made to, like one that
+// is down.
+type unavailableDB struct{}
+
+func (unavailableDB) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func (unavailableDB) Driver() driver.Driver { return unavailableDB{} }
+
+func (unavailableDB) Open(string) (driver.Conn, error) {
+	return nil, errors.New("database unavailable")
+}
+
+// newTestAPI serves the handlers behind the error middleware, as the
+// service does. The core has no database to reach, the cases below are
+// answered before it is needed or are about it failing.
+func newTestAPI() *api.API {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil, middleware.Errors(log))
+
+	db := sqlx.NewDb(sql.OpenDB(unavailableDB{}), "mysql")
+	h := employeegrp.Handlers{Log: log, Employee: employee.NewCore(log, db)}
+	a.Handle(http.MethodPost, "/v1/employee", h.Create)
+	a.Handle(http.MethodPatch, "/v1/employee/{id}", h.Update)
+	a.Handle(http.MethodPost, "/v1/employee:batch", h.Batch)
+	a.Handle(http.MethodGet, "/v1/employee/export", h.Export)
+
+	return a
+}
+
+func Test_DecodeStatus(t *testing.T) {
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "create unsupported", method: http.MethodPost, path: "/v1/employee", contentType: "text/plain", body: "Jo", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "update unsupported", method: http.MethodPatch, path: "/v1/employee/1", contentType: "application/yaml", body: "name: Jo", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "batch unsupported", method: http.MethodPost, path: "/v1/employee:batch", contentType: "text/csv", body: "op\ncreate", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "create invalid json", method: http.MethodPost, path: "/v1/employee", contentType: "application/json", body: `{"name":`, wantStatus: http.StatusBadRequest},
+		{name: "create invalid xml", method: http.MethodPost, path: "/v1/employee", contentType: "application/xml", body: "<employee><name>", wantStatus: http.StatusBadRequest},
+	}
+
+	t.Log("Given the need to answer undecodable bodies with the status Decode chose")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		newTestAPI().ServeHTTP(w, r)
+
+		if w.Code != tc.wantStatus {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould answer %d, got %d %q", Failed, testID, tc.name, tc.wantStatus, w.Code, w.Body.String())
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould answer %d", Success, testID, tc.name, tc.wantStatus)
+	}
+}
+
+func Test_ExportParams(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown format", query: "format=pdf"},
+		{name: "blank format", query: "format=%20"},
+		{name: "invalid department", query: "department=abc"},
+	}
+
+	t.Log("Given the need to refuse invalid exports before they start")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/v1/employee/export?"+tc.query, nil)
+		w := httptest.NewRecorder()
+		newTestAPI().ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest || w.Header().Get("Content-Disposition") != "" {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould answer 400, got %d %q", Failed, testID, tc.name, w.Code, w.Body.String())
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould answer 400", Success, testID, tc.name)
+	}
+}
+
+func Test_BatchTransactionFailure(t *testing.T) {
+	body := `{"atomic": false, "operations": [{"op": "create", "employee": {"name": "Sachin Prasad"}}]}`
+
+	t.Log("Given the need to report operations whose transaction could not run")
+	{
+		testID := 0
+		r := httptest.NewRequest(http.MethodPost, "/v1/employee:batch", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newTestAPI().ServeHTTP(w, r)
+
+		var resp struct {
+			Data []employeegrp.BatchItem `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("\t%s\tTest %d:\tShould decode the response : %s %q", Failed, testID, err, w.Body.String())
+		}
+		if w.Code != http.StatusMultiStatus || len(resp.Data) != 1 || resp.Data[0].Status != http.StatusInternalServerError || resp.Data[0].Data != nil {
+			t.Fatalf("\t%s\tTest %d:\tShould report the operation as failed, got %d %q", Failed, testID, w.Code, w.Body.String())
+		}
+		t.Logf("\t%s\tTest %d:\tShould report the operation as failed", Success, testID)
+	}
+}
@@ -0,0 +1,25 @@
+package employeegrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+)
+
+// ifMatch returns the state the If-Match header expects the employee to be
+// in, nil when the header is missing. The employee must have one of the
+// listed tags, "*" only asks for it to exist. Requests without the header
+// are refused with 428 when RequireIfMatch is set.
+func (h Handlers) ifMatch(r *http.Request) (employee.Match, error) {
+	tags := api.ETags(r.Header.Get("If-Match"))
+	if len(tags) == 0 {
+		if h.RequireIfMatch {
+			return nil, api.NewRequestError(errors.New("If-Match header is required"), http.StatusPreconditionRequired)
+		}
+		return nil, nil
+	}
+
+	return employee.MatchETag(tags...), nil
+}
@@ -0,0 +1,365 @@
+package employeegrp
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// Content type of NDJSON exports, CSV and XLSX share theirs with the import.
+const contentTypeNDJSON = "application/x-ndjson"
+
+// exportFlushRows is how many rows are written between two flushes, so the
+// client receives the export as it is read.
+const exportFlushRows = 500
+
+// exportColumns is the header of the CSV and XLSX exports.
+var exportColumns = []string{
+	"public_id", "id", "name", "position", "position_id", "department_id",
+	"manager_id", "version", "created_on", "updated_on", "deleted_on",
+}
+
+// exportCompleteTrailer is the HTTP trailer telling whether the export
+// was sent whole, it is false when it was cut short by an error.
+const exportCompleteTrailer = "X-Export-Complete"
+
+// exporter writes the employees of an export in one format.
+type exporter interface {
+	write(emp employee.Employee) error
+	flush() error
+	close() error
+	abort()
+}
+
+// exportSource calls fn with every employee matching the filter, like
+// employee.Core.Export.
+type exportSource func(ctx context.Context, filter employee.QueryFilter, fn func(employee.Employee) error) error
+
+// Export employees
+//
+// swagger:operation GET /employee/export Employee EmployeeExport
+//
+// # Download every Employee matching the filters
+//
+// The listing filters apply, paging does not: every employee is streamed
+// in id order as csv (the default) or ndjson, or sent as an xlsx workbook
+// once complete. Streamed exports end with the X-Export-Complete trailer,
+// true unless an error cut the export short.
+//
+// ---
+// produces:
+// - text/csv
+// - application/x-ndjson
+// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// responses:
+//
+//	  "200":
+//		   description: The employees, one per row or line
+//		   headers:
+//		     X-Export-Complete:
+//		       type: string
+//		       description: Trailer, true once every employee was sent
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+func (h Handlers) Export(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.export(ctx, w, r, h.Employee.Export)
+}
+
+// export sends the employees read from source in the requested format.
+func (h Handlers) export(ctx context.Context, w http.ResponseWriter, r *http.Request, source exportSource) error {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	var contentType string
+	var newExporter func(io.Writer) exporter
+	switch format {
+	case "csv":
+		contentType, newExporter = contentTypeCSV, newCSVExporter
+	case "ndjson":
+		contentType, newExporter = contentTypeNDJSON, newNDJSONExporter
+	case "xlsx":
+		contentType, newExporter = contentTypeXLSX, newXLSXExporter
+	default:
+		return validate.FieldErrors{FieldError: []validate.FieldError{{Field: "format", Error: "format must be one of csv, ndjson or xlsx"}}}
+	}
+
+	filter, err := filterParams(r)
+	if err != nil {
+		return err
+	}
+
+	// The response is sent with the first bytes of the export, errors met
+	// before are answered like any other call.
+	out := &exportWriter{w: w, begin: func() {
+		filename := fmt.Sprintf("employees-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Trailer", exportCompleteTrailer)
+
+		// An export outlasts the write timeout meant for regular calls.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		_ = api.SetStatusCode(ctx, http.StatusOK)
+		w.WriteHeader(http.StatusOK)
+	}}
+	ex := newExporter(out)
+
+	rows := 0
+	err = source(ctx, filter, func(emp employee.Employee) error {
+		if err := ex.write(emp); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := ex.flush(); err != nil {
+				return err
+			}
+			if out.begun {
+				_ = http.NewResponseController(w).Flush()
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = ex.close()
+	} else {
+		ex.abort()
+	}
+
+	if !out.begun {
+		if err != nil {
+			if errors.Is(err, employee.ErrInvalidDepartment) {
+				return api.NewRequestError(err, http.StatusBadRequest)
+			}
+			return fmt.Errorf("unable to export Employee: %w", err)
+		}
+		out.start()
+	}
+
+	// Part of the export is on its way, all that is left is to cut it short,
+	// tell the client through the trailer and record why.
+	complete := "true"
+	if err != nil {
+		complete = "false"
+		_ = api.SetIsError(ctx)
+		if h.Log != nil {
+			h.Log.Error("export", "tracer_uid", api.GetTracerUID(ctx), "rows", rows, slog.Any("ERROR", err))
+		}
+	}
+	w.Header().Set(exportCompleteTrailer, complete)
+
+	return nil
+}
+
+// exportWriter sends the response header before the first bytes written
+// to it.
+type exportWriter struct {
+	w     io.Writer
+	begin func()
+	begun bool
+}
+
+func (ew *exportWriter) start() {
+	if !ew.begun {
+		ew.begun = true
+		ew.begin()
+	}
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	ew.start()
+	return ew.w.Write(p)
+}
+
+// exportRecord is the employee as a row of the CSV and XLSX exports.
+func exportRecord(emp employee.Employee) []string {
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	deletedOn := ""
+	if emp.DeletedOn != nil {
+		deletedOn = emp.DeletedOn.Format(time.RFC3339)
+	}
+
+	return []string{
+		emp.PublicID,
+		emp.ID,
+		emp.Name,
+		emp.Position,
+		optional(emp.PositionID),
+		optional(emp.DepartmentID),
+		optional(emp.ManagerID),
+		strconv.Itoa(emp.Version),
+		emp.CreatedOn.Format(time.RFC3339),
+		emp.UpdatedOn.Format(time.RFC3339),
+		deletedOn,
+	}
+}
+
+// csvExporter writes the export as CSV with a header row.
+type csvExporter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVExporter(w io.Writer) exporter {
+	return &csvExporter{w: csv.NewWriter(w)}
+}
+
+func (e *csvExporter) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(exportColumns)
+}
+
+func (e *csvExporter) write(emp employee.Employee) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.w.Write(exportRecord(emp))
+}
+
+func (e *csvExporter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+// abort drops the buffered rows, the trailer tells the export is cut short
+// once sent and nothing is sent otherwise.
+func (e *csvExporter) abort() {}
+
+// ndjsonExporter writes the export as one JSON employee per line.
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONExporter(w io.Writer) exporter {
+	return ndjsonExporter{enc: json.NewEncoder(w)}
+}
+
+func (e ndjsonExporter) write(emp employee.Employee) error {
+	return e.enc.Encode(emp)
+}
+
+func (e ndjsonExporter) flush() error {
+	return nil
+}
+
+func (e ndjsonExporter) close() error {
+	return nil
+}
+
+func (e ndjsonExporter) abort() {}
+
+// xlsxExporter writes the export as a single sheet workbook. The workbook
+// format is a zip archive that is only sent once complete, so a failed
+// export is answered like any other call instead of as a truncated file.
+// The rows are kept by the stream writer, which moves them to a temporary
+// file past a few megabytes, and the archive is built in a temporary file,
+// rather than in memory.
+type xlsxExporter struct {
+	out io.Writer
+	f   *excelize.File
+	sw  *excelize.StreamWriter
+	row int
+	err error
+}
+
+func newXLSXExporter(w io.Writer) exporter {
+	e := xlsxExporter{out: w, f: excelize.NewFile(), row: 1}
+
+	e.sw, e.err = e.f.NewStreamWriter(e.f.GetSheetName(0))
+	if e.err == nil {
+		e.err = e.writeRow(exportColumns)
+	}
+
+	return &e
+}
+
+func (e *xlsxExporter) writeRow(record []string) error {
+	cells := make([]interface{}, len(record))
+	for i, v := range record {
+		cells[i] = v
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	e.row++
+
+	return e.sw.SetRow(cell, cells)
+}
+
+func (e *xlsxExporter) write(emp employee.Employee) error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.writeRow(exportRecord(emp))
+}
+
+func (e *xlsxExporter) flush() error {
+	return e.err
+}
+
+func (e *xlsxExporter) close() error {
+	defer e.f.Close()
+
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "employees-*.xlsx")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := e.f.WriteTo(tmp); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(e.out, tmp)
+	return err
+}
+
+func (e *xlsxExporter) abort() {
+	_ = e.f.Close()
+}
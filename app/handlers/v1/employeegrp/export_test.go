@@ -0,0 +1,88 @@
+package employeegrp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pansachin/employee-service/models/employee"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func Test_Export(t *testing.T) {
+	emp := employee.Employee{
+		PublicID:  "01906a4e-8c2b-7b3e-9f4a-2d5c6e7f8a9b",
+		ID:        "1",
+		Name:      "Sachin Prasad",
+		Version:   1,
+		CreatedOn: time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedOn: time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC),
+	}
+	errRead := errors.New("connection lost")
+
+	// rowsThen sends the employees and ends with err.
+	rowsThen := func(n int, err error) exportSource {
+		return func(_ context.Context, _ employee.QueryFilter, fn func(employee.Employee) error) error {
+			for i := 0; i < n; i++ {
+				if err := fn(emp); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	cases := []struct {
+		name         string
+		format       string
+		source       exportSource
+		wantErr      bool
+		wantBody     string
+		wantComplete string
+	}{
+		{name: "empty csv", format: "csv", source: rowsThen(0, nil), wantBody: strings.Join(exportColumns, ",") + "\n", wantComplete: "true"},
+		{name: "empty ndjson", format: "ndjson", source: rowsThen(0, nil), wantBody: "", wantComplete: "true"},
+		{name: "csv failing before any row", format: "csv", source: rowsThen(0, errRead), wantErr: true},
+		{name: "ndjson failing after a row", format: "ndjson", source: rowsThen(1, errRead), wantBody: `"name":"Sachin Prasad"`, wantComplete: "false"},
+		{name: "xlsx failing after a row", format: "xlsx", source: rowsThen(1, errRead), wantErr: true},
+		{name: "xlsx", format: "xlsx", source: rowsThen(2, nil), wantBody: "PK", wantComplete: "true"},
+	}
+
+	h := Handlers{Log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	t.Log("Given the need to tell complete exports from truncated ones")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/v1/employee/export?format="+tc.format, nil)
+		w := httptest.NewRecorder()
+		err := h.export(context.Background(), w, r, tc.source)
+
+		if tc.wantErr {
+			if err == nil || w.Header().Get("Content-Disposition") != "" || w.Body.Len() != 0 {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould answer the error instead of the export, got %v %q", failed, testID, tc.name, err, w.Body.String())
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould answer the error instead of the export", success, testID, tc.name)
+			continue
+		}
+
+		res := w.Result()
+		body := w.Body.String()
+		if err != nil || res.StatusCode != http.StatusOK || !strings.Contains(body, tc.wantBody) || (tc.wantBody == "" && body != "") {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould send the export, got %v %d %q", failed, testID, tc.name, err, res.StatusCode, body)
+		}
+		if got := res.Trailer.Get(exportCompleteTrailer); got != tc.wantComplete {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould end with %s %q, got %q", failed, testID, tc.name, exportCompleteTrailer, tc.wantComplete, got)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould end with %s %q", success, testID, tc.name, exportCompleteTrailer, tc.wantComplete)
+	}
+}
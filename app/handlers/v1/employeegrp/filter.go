@@ -0,0 +1,140 @@
+package employeegrp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// dateLayouts are the accepted formats of the date filters.
+var dateLayouts = []string{time.RFC3339, time.DateOnly}
+
+// filterParams reads the filters of the employee listing from the query
+// string. Every invalid parameter is reported as a field error.
+func filterParams(r *http.Request) (employee.QueryFilter, error) {
+	qparams := r.URL.Query()
+
+	var filter employee.QueryFilter
+	var fields validate.FieldErrors
+	fieldErr := func(field string, msg string) {
+		fields.FieldError = append(fields.FieldError, validate.FieldError{Field: field, Error: msg})
+	}
+
+	if val := qparams.Get("department"); val != "" {
+		if err := validate.CheckID(val); err != nil {
+			fieldErr("department", employee.ErrInvalidDepartment.Error())
+		}
+		filter.DepartmentID = &val
+	}
+
+	if val, ok := qparams["name"]; ok {
+		name := strings.TrimSpace(val[0])
+		if name == "" {
+			fieldErr("name", "name cannot be blank")
+		}
+		filter.NamePrefix = &name
+	}
+
+	if val, ok := qparams["name_contains"]; ok {
+		name := strings.TrimSpace(val[0])
+		if name == "" {
+			fieldErr("name_contains", "name_contains cannot be blank")
+		}
+		filter.NameContains = &name
+	}
+
+	if val, ok := qparams["position"]; ok {
+		pos := strings.TrimSpace(val[0])
+		if pos == "" {
+			fieldErr("position", "position cannot be blank")
+		}
+		filter.Position = &pos
+	}
+
+	dates := []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+	}
+	for _, d := range dates {
+		val := qparams.Get(d.name)
+		if val == "" {
+			continue
+		}
+		t, ok := parseDate(val)
+		if !ok {
+			fieldErr(d.name, d.name+" must be a date (2006-01-02) or an RFC 3339 timestamp")
+			continue
+		}
+		*d.dest = &t
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		fieldErr("created_before", "created_before must be later than created_after")
+	}
+
+	if val := qparams.Get("include_deleted"); val != "" {
+		include, err := strconv.ParseBool(val)
+		if err != nil {
+			fieldErr("include_deleted", "include_deleted must be true or false")
+		}
+		filter.IncludeDeleted = include
+	}
+
+	if len(fields.FieldError) > 0 {
+		return employee.QueryFilter{}, fields
+	}
+
+	return filter, nil
+}
+
+// countParam tells whether the listing should be counted, it is unless
+// count=false is given.
+func countParam(r *http.Request) (bool, error) {
+	val := r.URL.Query().Get("count")
+	if val == "" {
+		return true, nil
+	}
+
+	count, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, validate.FieldErrors{FieldError: []validate.FieldError{{Field: "count", Error: "count must be true or false"}}}
+	}
+
+	return count, nil
+}
+
+// asOfParam reads the instant of a point-in-time read, nil when the
+// current state is asked for.
+func asOfParam(r *http.Request) (*time.Time, error) {
+	val := r.URL.Query().Get("as_of")
+	if val == "" {
+		return nil, nil
+	}
+
+	t, ok := parseDate(val)
+	if !ok {
+		return nil, validate.FieldErrors{FieldError: []validate.FieldError{{Field: "as_of", Error: "as_of must be a date (2006-01-02) or an RFC 3339 timestamp"}}}
+	}
+	if r.URL.Query().Has("cursor") {
+		return nil, validate.FieldErrors{FieldError: []validate.FieldError{{Field: "cursor", Error: "cursor cannot be combined with as_of, use page"}}}
+	}
+
+	return &t, nil
+}
+
+// parseDate parses the value using the accepted date layouts, in UTC.
+func parseDate(val string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
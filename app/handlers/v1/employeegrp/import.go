@@ -0,0 +1,185 @@
+package employeegrp
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// Content types accepted by the import.
+const (
+	contentTypeCSV  = "text/csv"
+	contentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// maxImportBytes is the largest file accepted by the import.
+const maxImportBytes = 10 << 20
+
+// importColumns maps the header of an import to the NewEmployee fields.
+var importColumns = map[string]func(ne *employee.NewEmployee, val string){
+	"name":          func(ne *employee.NewEmployee, val string) { ne.Name = val },
+	"position":      func(ne *employee.NewEmployee, val string) { ne.Position = val },
+	"position_id":   func(ne *employee.NewEmployee, val string) { ne.PositionID = &val },
+	"department_id": func(ne *employee.NewEmployee, val string) { ne.DepartmentID = &val },
+	"manager_id":    func(ne *employee.NewEmployee, val string) { ne.ManagerID = &val },
+}
+
+// Import employees from a spreadsheet
+//
+// swagger:operation POST /employee/import Employee EmployeeImport
+//
+// # Create Employees from a CSV or XLSX file
+//
+// The first row names the columns: name, position, position_id,
+// department_id and manager_id. Every valid row is created in a single
+// transaction, the others are reported with their errors. `dry_run`
+// reports the same outcome without writing anything.
+//
+// ---
+// consumes:
+// - text/csv
+// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/EmployeeImportRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "415":
+//		   "$ref": "#/responses/errorResponse415"
+func (h Handlers) Import(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	dryRun := false
+	if val := r.URL.Query().Get("dry_run"); val != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(val); err != nil {
+			return validate.FieldErrors{FieldError: []validate.FieldError{{Field: "dry_run", Error: "dry_run must be true or false"}}}
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	var records [][]string
+	switch mediaType {
+	case contentTypeCSV:
+		records, err = readCSV(body)
+	case contentTypeXLSX:
+		records, err = readXLSX(body)
+	default:
+		return api.NewRequestError(fmt.Errorf("content type must be %s or %s", contentTypeCSV, contentTypeXLSX), http.StatusUnsupportedMediaType)
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return api.NewRequestError(fmt.Errorf("file is larger than %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		}
+		return api.NewRequestError(fmt.Errorf("reading file: %w", err), http.StatusBadRequest)
+	}
+
+	rows, err := importRows(records)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	sum, err := h.Employee.Import(ctx, rows, dryRun, now)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	return api.Respond(ctx, w, sum, http.StatusOK)
+}
+
+// readCSV reads every record of a CSV file, rows may differ in length.
+func readCSV(r io.Reader) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	return cr.ReadAll()
+}
+
+// readXLSX reads the rows of the first sheet of a workbook.
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no sheet")
+	}
+
+	return f.GetRows(sheets[0])
+}
+
+// importRows maps the records after the header to new employees. Cells are
+// trimmed, empty optional cells are left unset.
+func importRows(records [][]string) ([]employee.ImportRow, error) {
+	headerErr := func(msg string) error {
+		return validate.FieldErrors{FieldError: []validate.FieldError{{Field: "header", Error: msg}}}
+	}
+
+	if len(records) == 0 {
+		return nil, headerErr("a header row is required")
+	}
+
+	setters := make([]func(*employee.NewEmployee, string), len(records[0]))
+	seen := make(map[string]bool)
+	for i, col := range records[0] {
+		col = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		set, ok := importColumns[col]
+		if !ok {
+			return nil, headerErr(fmt.Sprintf("unknown column %q", col))
+		}
+		if seen[col] {
+			return nil, headerErr(fmt.Sprintf("column %q is repeated", col))
+		}
+		seen[col] = true
+		setters[i] = set
+	}
+	if !seen["name"] {
+		return nil, headerErr("a name column is required")
+	}
+
+	rows := make([]employee.ImportRow, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		row := employee.ImportRow{Line: i + 2, Blank: true}
+		for j, val := range rec {
+			val = strings.TrimSpace(val)
+			if val == "" || j >= len(setters) {
+				continue
+			}
+			setters[j](&row.Employee, val)
+			row.Blank = false
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -1,6 +1,9 @@
 package employeegrp
 
-import "github.com/pansachin/employee-service/models/employee"
+import (
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/database"
+)
 
 // swagger:response EmployeeRes
 type _ struct {
@@ -19,17 +22,290 @@ type _ struct {
 	}
 }
 
-// swagger:parameters EmployeeQueryById EmployeeDelete EmployeeUpdate EmployeeUnDelete
+// swagger:response EmployeeListRes
 type _ struct {
-	// Employee ID
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []employee.Employee `json:"data"`
+		// Cursor to the next page
+		NextCursor string `json:"next_cursor,omitempty"`
+		// Cursor to the previous page
+		PrevCursor string `json:"prev_cursor,omitempty"`
+		// Total count and paging details, omitted with count=false
+		Meta *database.PaginationResults `json:"meta,omitempty"`
+	}
+}
+
+// swagger:response EmployeeHistoryRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []employee.Change `json:"data"`
+		// Total count and paging details
+		Meta *database.PaginationResults `json:"meta,omitempty"`
+	}
+}
+
+// swagger:response PendingChangeRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []employee.PendingChange `json:"data"`
+	}
+}
+
+// swagger:response EmployeeBatchRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []BatchItem `json:"data"`
+	}
+}
+
+// swagger:response EmployeeImportRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data employee.ImportSummary `json:"data"`
+	}
+}
+
+// swagger:response OrgChartRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []employee.OrgNode `json:"data"`
+	}
+}
+
+// swagger:parameters EmployeeQueryById EmployeeDelete EmployeeUpdate EmployeeUnDelete EmployeeReports EmployeeChain EmployeeHistory EmployeePendingChanges EmployeeCancelPendingChange
+type _ struct {
+	// Employee ID or public ID
 	//
 	// in: path
 	// required: true
-	// enum: 1
-	// type: integer
+	// type: string
+	// example: 01906a4e-8c2b-7b3e-9f4a-2d5c6e7f8a9b
 	ID string `json:"id"`
 }
 
+// swagger:parameters EmployeeCancelPendingChange
+type _ struct {
+	// Pending change public ID
+	//
+	// in: path
+	// required: true
+	// type: string
+	// example: 01906a4f-1c2d-7e3f-8a4b-5c6d7e8f9a0b
+	ChangeID string `json:"change_id"`
+}
+
+// swagger:parameters EmployeeDelete EmployeeUpdate
+type _ struct {
+	// ETags of the employee the change is based on, comma separated, the
+	// change is refused with 412 unless the employee still has one of
+	// them. * only requires the employee to exist
+	//
+	// in: header
+	// required: false
+	// type: string
+	// example: "9b2f0c4e7d1a85e3f6a4c0b1d2e3f405"
+	IfMatch string `json:"If-Match"`
+}
+
+// swagger:parameters EmployeeQuery EmployeeQueryById
+type _ struct {
+	// Return the data as it was at the date (2006-01-02) or RFC 3339
+	// timestamp, employees deleted since included. Listings are paged by
+	// page number, cursor cannot be combined with it
+	//
+	// in: query
+	// required: false
+	// type: string
+	AsOf string `json:"as_of"`
+}
+
+// swagger:parameters EmployeeQueryById
+type _ struct {
+	// ETag of a copy held by the client, answered with 304 while it is
+	// current
+	//
+	// in: header
+	// required: false
+	// type: string
+	// example: "9b2f0c4e7d1a85e3f6a4c0b1d2e3f405"
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
+// swagger:parameters EmployeeQuery EmployeeExport
+type _ struct {
+	// Only list the employees assigned to the department
+	//
+	// in: query
+	// required: false
+	// type: integer
+	Department string `json:"department"`
+	// Only list the employees whose name starts with the value
+	//
+	// in: query
+	// required: false
+	// type: string
+	Name string `json:"name"`
+	// Only list the employees whose name contains the value
+	//
+	// in: query
+	// required: false
+	// type: string
+	NameContains string `json:"name_contains"`
+	// Only list the employees holding the position, by ID or title
+	//
+	// in: query
+	// required: false
+	// type: string
+	Position string `json:"position"`
+	// Only list the employees created at or after the date (2006-01-02) or
+	// RFC 3339 timestamp
+	//
+	// in: query
+	// required: false
+	// type: string
+	CreatedAfter string `json:"created_after"`
+	// Only list the employees created before the date (2006-01-02) or
+	// RFC 3339 timestamp
+	//
+	// in: query
+	// required: false
+	// type: string
+	CreatedBefore string `json:"created_before"`
+	// Only list the employees updated at or after the date (2006-01-02) or
+	// RFC 3339 timestamp
+	//
+	// in: query
+	// required: false
+	// type: string
+	UpdatedAfter string `json:"updated_after"`
+	// Include soft deleted employees
+	//
+	// in: query
+	// required: false
+	// type: boolean
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
+// swagger:parameters EmployeeQuery
+type _ struct {
+	// Count the matching employees and return them in meta.total, false
+	// skips the extra query and the meta block
+	//
+	// in: query
+	// required: false
+	// type: boolean
+	// default: true
+	Count bool `json:"count"`
+}
+
+// swagger:parameters EmployeeHistory
+type _ struct {
+	// The current page
+	//
+	// in: query
+	// required: false
+	// type: integer
+	// minimum: 1
+	Page int `json:"page"`
+	// The per page limit
+	//
+	// in: query
+	// required: false
+	// type: integer
+	// minimum: 1
+	// maximum: 100
+	PerPage int `json:"per_page"`
+	// The direction of the history, newest first by default
+	//
+	// in: query
+	// required: false
+	// type: string
+	// enum: asc,desc
+	Direction string `json:"direction"`
+}
+
+// swagger:parameters EmployeeReports OrgChart
+type _ struct {
+	// Levels of reports to include
+	//
+	// in: query
+	// required: false
+	// type: integer
+	// minimum: 1
+	// maximum: 32
+	Depth int `json:"depth"`
+}
+
+// swagger:parameters OrgChart
+type _ struct {
+	// Only return the tree below this employee, by ID or public ID
+	//
+	// in: query
+	// required: false
+	// type: string
+	Root string `json:"root"`
+}
+
 // swagger:parameters EmployeeCreate
 type _ struct {
 	// The body to create a employee
@@ -45,3 +321,37 @@ type _ struct {
 	// required: true
 	Body employee.UpdateEmployee
 }
+
+// swagger:parameters EmployeeBatch
+type _ struct {
+	// The operations to apply
+	// in:body
+	// required: true
+	Body employee.Batch
+}
+
+// swagger:parameters EmployeeImport
+type _ struct {
+	// Report the outcome of every row without writing anything
+	//
+	// in: query
+	// required: false
+	// type: boolean
+	DryRun bool `json:"dry_run"`
+	// The spreadsheet, a header row followed by one employee per row
+	// in:body
+	// required: true
+	Body string
+}
+
+// swagger:parameters EmployeeExport
+type _ struct {
+	// Format of the export
+	//
+	// in: query
+	// required: false
+	// type: string
+	// enum: csv,ndjson,xlsx
+	// default: csv
+	Format string `json:"format"`
+}
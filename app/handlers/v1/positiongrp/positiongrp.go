@@ -0,0 +1,257 @@
+// Package positiongrp for position handler functions
+package positiongrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pansachin/employee-service/models/position"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// Handlers manages the set of position endpoints.
+type Handlers struct {
+	Position position.Core
+}
+
+// Create a new position record
+//
+// # Create a new Position record
+//
+// ---
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+//
+//swagger:operation POST /position Position PositionCreate
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	np := position.NewPosition{}
+	if err := api.Decode(r, &np); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	data, err := h.Position.Create(ctx, np, now)
+	if err != nil {
+		return fmt.Errorf("creating position: %w", err)
+	}
+
+	return api.Respond(ctx, w, []position.Position{data}, http.StatusOK)
+}
+
+// Query all the Position records
+//
+// swagger:operation GET /position Position PositionQuery
+//
+// # This is the summary for listing Positions
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pagi, err := database.PaginationParams(r)
+	if err != nil {
+		return err
+	}
+
+	rs, err := h.Position.Query(ctx, pagi)
+	if err != nil {
+		return fmt.Errorf("unable to query for Position: %w", err)
+	}
+
+	return api.Respond(ctx, w, rs, http.StatusOK)
+}
+
+// QueryByID from an individual id
+//
+// swagger:operation GET /position/{id} Position PositionQueryById
+//
+// # Getting a single Position by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	rs, err := h.Position.QueryByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, position.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, position.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("position id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, []position.Position{rs}, http.StatusOK)
+}
+
+// Delete from an individual id
+//
+// swagger:operation DELETE /position/{id} Position PositionDelete
+//
+// # Delete a single Position by ID
+//
+// Positions that still have active employees cannot be deleted.
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	now := time.Now().UTC()
+
+	err := h.Position.Delete(ctx, id, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, position.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, position.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, position.ErrHasEmployees):
+			return api.NewRequestError(position.ErrHasEmployees, http.StatusConflict)
+		default:
+			return fmt.Errorf("position id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// Update from an individual id
+//
+// swagger:operation PATCH /position/{id} Position PositionUpdate
+//
+// # Update a single Position by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+//	  "404":
+//		   "$ref": "#/responses/errorResponse404"
+//	  "409":
+//		   "$ref": "#/responses/errorResponse409"
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	up := position.UpdatePosition{}
+	if err := api.Decode(r, &up); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err := h.Position.Update(ctx, id, up, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, position.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, position.ErrNotFound):
+			return api.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("position id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
+
+// UnDelete from an individual id
+//
+// swagger:operation PATCH /position/undelete/{id} Position PositionUnDelete
+//
+// # UnDelete a single Position by ID
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//
+//	  "200":
+//		   "$ref": "#/responses/PositionRes"
+//	  "400":
+//		   "$ref": "#/responses/errorResponse400"
+//	  "401":
+//		   "$ref": "#/responses/errorResponse401"
+//	  "403":
+//		   "$ref": "#/responses/errorResponse403"
+func (h Handlers) UnDelete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := api.Param(r, "id")
+
+	now := time.Now().UTC()
+
+	err := h.Position.UnDelete(ctx, id, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, position.ErrInvalidID):
+			return api.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("position id[%s]: %w", id, err)
+		}
+	}
+
+	return api.Respond(ctx, w, nil, http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package positiongrp
+
+import "github.com/pansachin/employee-service/models/position"
+
+// swagger:response PositionRes
+type _ struct {
+	// in:body
+	Body struct {
+		// Success
+		//
+		Success bool `json:"success"`
+		// Timestamp
+		//
+		// example: 1639237536
+		Timestamp int64 `json:"timestamp"`
+		// Data
+		// in: body
+		Data []position.Position `json:"data"`
+	}
+}
+
+// swagger:parameters PositionQueryById PositionDelete PositionUpdate PositionUnDelete
+type _ struct {
+	// Position ID
+	//
+	// in: path
+	// required: true
+	// enum: 1
+	// type: integer
+	ID string `json:"id"`
+}
+
+// swagger:parameters PositionCreate
+type _ struct {
+	// The body to create a position
+	// in:body
+	// required: true
+	Body position.NewPosition
+}
+
+// swagger:parameters PositionUpdate
+type _ struct {
+	// The body to update a position
+	// in:body
+	// required: true
+	Body position.UpdatePosition
+}
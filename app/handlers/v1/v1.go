@@ -6,20 +6,32 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/pansachin/employee-service/app/handlers/checkgrp"
+	"github.com/pansachin/employee-service/app/handlers/v1/departmentgrp"
 	"github.com/pansachin/employee-service/app/handlers/v1/employeegrp"
+	"github.com/pansachin/employee-service/app/handlers/v1/positiongrp"
+	"github.com/pansachin/employee-service/models/department"
 	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/models/position"
 	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/api/middleware"
+	"github.com/pansachin/employee-service/pkg/auth"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log   *slog.Logger
-	DB    *sqlx.DB
-	RWMux *sync.RWMutex
+	Build          string
+	BuildTime      string
+	StartedOn      time.Time
+	Log            *slog.Logger
+	DB             *sqlx.DB
+	RequireIfMatch bool
+	Auth           *auth.Auth
+	Policy         *auth.Policy
 }
 
 // Routes binds all the version 1 routes.
@@ -27,15 +39,69 @@ func Routes(router *api.API, cfg Config) {
 	// -------------------------------------------------------------------
 	// Requesting Sources
 	// -------------------------------------------------------------------
+	authen := middleware.Authenticate(cfg.Log, cfg.Auth)
+	authorize := func(action string) api.Middleware {
+		return middleware.Authorize(cfg.Log, cfg.Policy, action)
+	}
+
 	rs := employeegrp.Handlers{
-		Employee: employee.NewCore(cfg.Log, cfg.DB, cfg.RWMux),
+		Log:            cfg.Log,
+		Employee:       employee.NewCore(cfg.Log, cfg.DB),
+		RequireIfMatch: cfg.RequireIfMatch,
+	}
+	router.Handle(http.MethodPost, "/v1/employee", rs.Create, authen, authorize(auth.ActionEmployeeCreate))
+	router.Handle(http.MethodPost, "/v1/employee:batch", rs.Batch, authen, authorize(auth.ActionEmployeeBatch))
+	router.Handle(http.MethodPost, "/v1/employee/import", rs.Import, authen, authorize(auth.ActionEmployeeCreate))
+	router.Handle(http.MethodGet, "/v1/employee", rs.Query, authen, authorize(auth.ActionEmployeeRead))
+	router.Handle(http.MethodGet, "/v1/employee/export", rs.Export, authen, authorize(auth.ActionEmployeeRead))
+	router.Handle(http.MethodGet, "/v1/employee/{id}", rs.QueryByID, authen, authorize(auth.ActionEmployeeRead))
+	router.Handle(http.MethodPatch, "/v1/employee/{id}", rs.Update, authen, authorize(auth.ActionEmployeeUpdate))
+	router.Handle(http.MethodDelete, "/v1/employee/{id}", rs.Delete, authen, authorize(auth.ActionEmployeeDelete))
+	router.Handle(http.MethodPatch, "/v1/employee/undelete/{id}", rs.UnDelete, authen, authorize(auth.ActionEmployeeUndelete))
+	router.Handle(http.MethodGet, "/v1/employee/{id}/reports", rs.Reports, authen, authorize(auth.ActionEmployeeRead))
+	router.Handle(http.MethodGet, "/v1/employee/{id}/chain", rs.Chain, authen, authorize(auth.ActionEmployeeRead))
+	router.Handle(http.MethodGet, "/v1/employee/{id}/history", rs.History, authen, authorize(auth.ActionEmployeeHistory))
+	router.Handle(http.MethodGet, "/v1/employee/{id}/pending-changes", rs.PendingChanges, authen, authorize(auth.ActionEmployeeUpdate))
+	router.Handle(http.MethodDelete, "/v1/employee/{id}/pending-changes/{change_id}", rs.CancelPendingChange, authen, authorize(auth.ActionEmployeeUpdate))
+	router.Handle(http.MethodGet, "/v1/orgchart", rs.OrgChart, authen, authorize(auth.ActionEmployeeRead))
+
+	// -------------------------------------------------------------------
+	// Departments
+	// -------------------------------------------------------------------
+	dg := departmentgrp.Handlers{
+		Department: department.NewCore(cfg.Log, cfg.DB),
+	}
+	router.Handle(http.MethodPost, "/v1/department", dg.Create, authen, authorize(auth.ActionDepartmentCreate))
+	router.Handle(http.MethodGet, "/v1/department", dg.Query, authen, authorize(auth.ActionDepartmentRead))
+	router.Handle(http.MethodGet, "/v1/department/{id}", dg.QueryByID, authen, authorize(auth.ActionDepartmentRead))
+	router.Handle(http.MethodPatch, "/v1/department/{id}", dg.Update, authen, authorize(auth.ActionDepartmentUpdate))
+	router.Handle(http.MethodDelete, "/v1/department/{id}", dg.Delete, authen, authorize(auth.ActionDepartmentDelete))
+	router.Handle(http.MethodPatch, "/v1/department/undelete/{id}", dg.UnDelete, authen, authorize(auth.ActionDepartmentUndelete))
+
+	// -------------------------------------------------------------------
+	// Positions
+	// -------------------------------------------------------------------
+	pg := positiongrp.Handlers{
+		Position: position.NewCore(cfg.Log, cfg.DB),
+	}
+	router.Handle(http.MethodPost, "/v1/position", pg.Create, authen, authorize(auth.ActionPositionCreate))
+	router.Handle(http.MethodGet, "/v1/position", pg.Query, authen, authorize(auth.ActionPositionRead))
+	router.Handle(http.MethodGet, "/v1/position/{id}", pg.QueryByID, authen, authorize(auth.ActionPositionRead))
+	router.Handle(http.MethodPatch, "/v1/position/{id}", pg.Update, authen, authorize(auth.ActionPositionUpdate))
+	router.Handle(http.MethodDelete, "/v1/position/{id}", pg.Delete, authen, authorize(auth.ActionPositionDelete))
+	router.Handle(http.MethodPatch, "/v1/position/undelete/{id}", pg.UnDelete, authen, authorize(auth.ActionPositionUndelete))
+
+	// -------------------------------------------------------------------
+	// Service Status
+	// -------------------------------------------------------------------
+	cg := checkgrp.Handlers{
+		Build:     cfg.Build,
+		BuildTime: cfg.BuildTime,
+		StartedOn: cfg.StartedOn,
+		Log:       cfg.Log,
+		DB:        cfg.DB,
 	}
-	router.Handle(http.MethodPost, "/v1/employee", rs.Create)
-	router.Handle(http.MethodGet, "/v1/employee", rs.Query)
-	router.Handle(http.MethodGet, "/v1/employee/{id}", rs.QueryByID)
-	router.Handle(http.MethodPatch, "/v1/employee/{id}", rs.Update)
-	router.Handle(http.MethodDelete, "/v1/employee/{id}", rs.Delete)
-	router.Handle(http.MethodPatch, "/v1/employee/undelete/{id}", rs.UnDelete)
+	router.Handle(http.MethodGet, "/v1/status", cg.Status)
 
 	// -------------------------------------------------------------------
 	// Add in the Teapot
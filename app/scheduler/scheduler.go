@@ -0,0 +1,61 @@
+// Package scheduler applies the employee changes recorded ahead of the
+// date they take effect on.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/api"
+)
+
+// Config contains all the mandatory systems required by the scheduler.
+type Config struct {
+	Log      *slog.Logger
+	Employee employee.Core
+	Interval time.Duration
+}
+
+// Run applies the due changes right away and then every interval, until
+// the context is cancelled. Instances running side by side share the work,
+// see employee.Core.ApplyPendingChanges.
+func Run(ctx context.Context, cfg Config) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		apply(ctx, cfg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// apply runs one pass over the due changes in its own trace.
+func apply(ctx context.Context, cfg Config) {
+	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "scheduler.pending-changes")
+	defer span.End()
+
+	now := time.Now().UTC()
+	v := api.ContextValues{
+		TracerUID: span.SpanContext().TraceID().String(),
+		Now:       now,
+	}
+	ctx = api.WithValues(ctx, &v)
+
+	n, err := cfg.Employee.ApplyPendingChanges(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		span.RecordError(err)
+		cfg.Log.Error("scheduler", "status", "applying pending changes", "tracer_uid", v.TracerUID, slog.Any("ERROR", err))
+	}
+	if n > 0 {
+		cfg.Log.Info("scheduler", "status", "applied pending changes", "count", n, "tracer_uid", v.TracerUID)
+	}
+}
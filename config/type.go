@@ -4,10 +4,12 @@ import "time"
 
 // ServiceConfig is the configuration for the service.
 type ServiceConfig struct {
-	App App `yaml:"app"`
-	Web Web `yaml:"web"`
-	Log Log `yaml:"log"`
-	Db  Db  `yaml:"db"`
+	App     App     `yaml:"app"`
+	Web     Web     `yaml:"web"`
+	Log     Log     `yaml:"log"`
+	Db      Db      `yaml:"db"`
+	Tracing Tracing `yaml:"tracing"`
+	Auth    Auth    `yaml:"auth"`
 }
 
 // App is the configuration for the app.
@@ -15,8 +17,15 @@ type App struct {
 	Name           string `yaml:"name"`
 	Env            string `yaml:"env"`
 	EnforceHeaders bool   `yaml:"enforceHeaders"`
+	RequireIfMatch bool   `yaml:"requireIfMatch"`
+	// ProblemDetails sends errors as RFC 7807 problem details to every
+	// client accepting JSON, not only to those asking for them.
+	ProblemDetails bool   `yaml:"problemDetails"`
 	TLS            bool   `yaml:"tls"`
 	Function       string `yaml:"function"`
+	// PendingChangeInterval is how often due pending changes are applied,
+	// zero disables the scheduler.
+	PendingChangeInterval time.Duration `yaml:"pendingChangeInterval"`
 }
 
 // Web is the configuration for the web.
@@ -27,8 +36,21 @@ type Web struct {
 	WriteTimeout      time.Duration `yaml:"writeTimeout"`
 	IdleTimeout       time.Duration `yaml:"idleTimeout"`
 	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+	DrainPeriod       time.Duration `yaml:"drainPeriod"`
 	APIHost           string        `yaml:"apiHost"`
 	APIPort           string        `yaml:"apiPort"`
+	AdminHost         string        `yaml:"adminHost"`
+	AdminPort         string        `yaml:"adminPort"`
+	CursorSecretFile  string        `yaml:"cursorSecretFile"`
+	TLS               TLS           `yaml:"tls"`
+}
+
+// TLS is the configuration for serving the api over TLS.
+type TLS struct {
+	CertFile   string `yaml:"certFile"`
+	KeyFile    string `yaml:"keyFile"`
+	CAFile     string `yaml:"caFile"`
+	ClientAuth string `yaml:"clientAuth"`
 }
 
 // Log is the configuration for the log.
@@ -50,3 +72,40 @@ type Db struct {
 	MaxOpenConns int    `yaml:"maxOpenConns"`
 	DisableTLS   bool   `yaml:"disableTLS"`
 }
+
+// Tracing is the configuration for the tracing.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	Probability float64 `yaml:"probability"`
+}
+
+// Auth is the configuration for the authentication.
+type Auth struct {
+	Enabled        bool     `yaml:"enabled"`
+	Issuer         string   `yaml:"issuer"`
+	Audience       string   `yaml:"audience"`
+	HMACSecretFile string   `yaml:"hmacSecretFile"`
+	PublicKeyFiles []string `yaml:"publicKeyFiles"`
+	JWKSFile       string   `yaml:"jwksFile"`
+	APIKeys        []APIKey `yaml:"apiKeys"`
+	// Policies maps an action to the roles allowed to perform it.
+	Policies map[string][]string `yaml:"policies"`
+	// ClientCerts maps verified client certificates to roles.
+	ClientCerts []CertRule `yaml:"clientCerts"`
+}
+
+// CertRule is the configuration for mapping a client certificate to roles.
+type CertRule struct {
+	OU    string   `yaml:"ou"`
+	CN    string   `yaml:"cn"`
+	Roles []string `yaml:"roles"`
+}
+
+// APIKey is the configuration for a static api key.
+type APIKey struct {
+	Name  string   `yaml:"name"`
+	Hash  string   `yaml:"hash"`
+	Roles []string `yaml:"roles"`
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS department (
+    id int unsigned auto_increment primary key,
+    name varchar(56) not null,
+    description varchar(256) not null default '',
+    created_on datetime not null default current_timestamp,
+    updated_on datetime not null default current_timestamp,
+    deleted_on datetime,
+    unique key uk_department_name (name)
+) engine = innodb;
+
+ALTER TABLE employee
+    ADD COLUMN department_id int unsigned default null AFTER position,
+    ADD KEY idx_employee_department_id (department_id),
+    ADD CONSTRAINT fk_employee_department FOREIGN KEY (department_id) REFERENCES department (id);
+
+insert into department (name, description) values
+  ('Engineering','Builds and runs the products')
+;
+
+update employee set department_id = (select id from department where name = 'Engineering');
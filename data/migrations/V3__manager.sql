@@ -0,0 +1,7 @@
+ALTER TABLE employee
+    ADD COLUMN manager_id tinyint unsigned default null AFTER department_id,
+    ADD KEY idx_employee_manager_id (manager_id),
+    ADD CONSTRAINT fk_employee_manager FOREIGN KEY (manager_id) REFERENCES employee (id);
+
+update employee set manager_id = (select id from (select id from employee where name = 'Ritesh Banerjee') m)
+where name in ('Sachin Prasad', 'Nadim Ayaz');
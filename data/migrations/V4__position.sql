@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS position (
+    id int unsigned auto_increment primary key,
+    title varchar(56) not null,
+    job_family varchar(56) not null default '',
+    level tinyint unsigned not null default 0,
+    created_on datetime not null default current_timestamp,
+    updated_on datetime not null default current_timestamp,
+    deleted_on datetime,
+    unique key uk_position_title (title)
+) engine = innodb;
+
+/* Clean up the free text before it becomes catalog entries */
+update employee set position = trim(position);
+update employee set position = 'Software Engineer' where position = 'Software Engineeri';
+
+insert into position (title, job_family, level)
+select distinct position, 'Engineering', 0 from employee where position <> '';
+
+ALTER TABLE employee
+    ADD COLUMN position_id int unsigned default null AFTER position,
+    ADD KEY idx_employee_position_id (position_id),
+    ADD CONSTRAINT fk_employee_position FOREIGN KEY (position_id) REFERENCES position (id);
+
+update employee e join position p on p.title = e.position set e.position_id = p.id;
+
+ALTER TABLE employee DROP COLUMN position;
@@ -0,0 +1,35 @@
+/* tinyint ids run out after 255 employees */
+ALTER TABLE employee DROP FOREIGN KEY fk_employee_manager;
+
+ALTER TABLE employee
+    MODIFY COLUMN id bigint unsigned auto_increment,
+    MODIFY COLUMN manager_id bigint unsigned default null;
+
+ALTER TABLE employee
+    ADD CONSTRAINT fk_employee_manager FOREIGN KEY (manager_id) REFERENCES employee (id);
+
+/* Opaque identifier exposed to clients instead of the sequential id */
+ALTER TABLE employee ADD COLUMN public_id char(36) default null AFTER id;
+
+/* Back-fill UUIDv7 values: 48 bit unix milliseconds of created_on, version
+   7, 12 random bits, variant 10 and 62 random bits. */
+update employee set public_id = lower(concat(
+    lpad(hex(floor(unix_timestamp(created_on) * 1000)), 12, '0'),
+    '7',
+    substr(hex(random_bytes(2)), 2, 3),
+    hex(8 | (ascii(random_bytes(1)) & 3)),
+    substr(hex(random_bytes(2)), 2, 3),
+    hex(random_bytes(6))
+)) where public_id is null;
+
+update employee set public_id = concat_ws('-',
+    substr(public_id, 1, 8),
+    substr(public_id, 9, 4),
+    substr(public_id, 13, 4),
+    substr(public_id, 17, 4),
+    substr(public_id, 21, 12)
+);
+
+ALTER TABLE employee
+    MODIFY COLUMN public_id char(36) not null,
+    ADD UNIQUE KEY uk_employee_public_id (public_id);
@@ -0,0 +1,3 @@
+/* Revision of the record, compared on every write so concurrent updates
+   from different replicas cannot overwrite each other. */
+ALTER TABLE employee ADD COLUMN version int unsigned not null default 1 AFTER manager_id;
@@ -0,0 +1,14 @@
+/* Every change of an employee, written in the transaction making it */
+CREATE TABLE IF NOT EXISTS employee_audit (
+    id bigint unsigned auto_increment primary key,
+    employee_id bigint unsigned not null,
+    action varchar(16) not null,
+    actor varchar(128) not null default '',
+    trace_id varchar(64) not null default '',
+    before_data json default null,
+    after_data json default null,
+    diff json not null,
+    created_on datetime not null default current_timestamp,
+    KEY idx_employee_audit_employee (employee_id, created_on),
+    CONSTRAINT fk_employee_audit_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+) engine = innodb;
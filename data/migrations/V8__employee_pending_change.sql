@@ -0,0 +1,18 @@
+/* Changes of an employee recorded ahead of the date they take effect on,
+   addressed by clients with their public id like employees are. */
+CREATE TABLE IF NOT EXISTS employee_pending_change (
+    id bigint unsigned auto_increment primary key,
+    public_id char(36) not null,
+    employee_id bigint unsigned not null,
+    changes json not null,
+    effective_on datetime not null,
+    status varchar(16) not null default 'pending',
+    error varchar(512) not null default '',
+    actor varchar(128) not null default '',
+    created_on datetime not null default current_timestamp,
+    updated_on datetime not null default current_timestamp,
+    UNIQUE KEY uk_employee_pending_change_public_id (public_id),
+    KEY idx_employee_pending_change_due (status, effective_on),
+    KEY idx_employee_pending_change_employee (employee_id, status),
+    CONSTRAINT fk_employee_pending_change_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+) engine = innodb;
@@ -0,0 +1,3 @@
+/* Attempts at applying a change that failed for a reason other than the
+   change being invalid, the change is marked failed after a few of them. */
+ALTER TABLE employee_pending_change ADD COLUMN attempts int unsigned not null default 0 AFTER error;
@@ -13,29 +13,45 @@
 //
 //	Consumes:
 //	- application/json
+//	- application/xml
+//	- application/msgpack
 //
 //	Produces:
 //	- application/json
+//	- application/xml
+//	- text/csv
+//	- application/msgpack
 //
 // swagger:meta
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	//nolint:all
 
 	"github.com/pansachin/employee-service/app/handlers"
+	"github.com/pansachin/employee-service/app/scheduler"
 	"github.com/pansachin/employee-service/config"
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/auth"
 	"github.com/pansachin/employee-service/pkg/database"
 	"github.com/pansachin/employee-service/pkg/logger"
+	"github.com/pansachin/employee-service/pkg/metrics"
+	"github.com/pansachin/employee-service/pkg/tracer"
 )
 
 const (
@@ -75,6 +91,7 @@ func main() {
 
 func run(log *slog.Logger) error {
 	var err error
+	startedOn := time.Now().UTC()
 	configYMLFile := "employee-service.config.yml"
 
 	c := config.NewConfig()
@@ -117,6 +134,29 @@ func run(log *slog.Logger) error {
 
 	fmt.Printf("employee-service-configs: %%#v: %#v\n", srvCfg)
 
+	// -------------------------------------------------------------------
+	// Tracing
+	// -------------------------------------------------------------------
+	log.Info("startup.tracer", "status", "initializing tracer")
+
+	traceShutdown, err := tracer.Init(context.Background(), log, tracer.Config{
+		ServiceName:    appName,
+		ServiceVersion: appVersionLDFlag,
+		Exporter:       srvCfg.Tracing.Exporter,
+		Endpoint:       srvCfg.Tracing.Endpoint,
+		Insecure:       srvCfg.Tracing.Insecure,
+		Probability:    srvCfg.Tracing.Probability,
+	})
+	if err != nil {
+		return fmt.Errorf("starting tracer: %w", err)
+	}
+	defer func() {
+		log.Info("shutdown", "status", "stopping tracer")
+		ctx, cancel := context.WithTimeout(context.Background(), srvCfg.Web.ShutdownTimeout)
+		defer cancel()
+		_ = traceShutdown(ctx)
+	}()
+
 	// -------------------------------------------------------------------
 	// Databases
 	// -------------------------------------------------------------------
@@ -141,33 +181,122 @@ func run(log *slog.Logger) error {
 		_ = db.Close()
 	}()
 
+	if err := metrics.RegisterDB(db.DB, srvCfg.Db.DbName); err != nil {
+		return fmt.Errorf("registering db metrics: %w", err)
+	}
+
+	// Pagination cursors are signed so callers cannot forge them. Sharing
+	// the key lets cursors move between instances and survive restarts.
+	if srvCfg.Web.CursorSecretFile != "" {
+		secret, err := os.ReadFile(srvCfg.Web.CursorSecretFile)
+		if err != nil {
+			return fmt.Errorf("reading cursor secret: %w", err)
+		}
+		secret = bytes.TrimSpace(secret)
+		if len(secret) == 0 {
+			return fmt.Errorf("cursor secret %s is empty", srvCfg.Web.CursorSecretFile)
+		}
+		database.SetCursorSecret(secret)
+	}
+
 	// -------------------------------------------------------------------
-	// RWMux for lock DBs in transaction mode (deadlocks = yuck)
+	// Authentication
 	// -------------------------------------------------------------------
-	log.Info("startup.remux", "status", "created")
-	rwmux := &sync.RWMutex{}
+	var authen *auth.Auth
+	var policy *auth.Policy
+	if srvCfg.Auth.Enabled {
+		log.Info("startup.auth", "status", "initializing authentication")
+
+		apiKeys := make([]auth.APIKey, len(srvCfg.Auth.APIKeys))
+		for i, k := range srvCfg.Auth.APIKeys {
+			apiKeys[i] = auth.APIKey{Name: k.Name, Hash: k.Hash, Roles: k.Roles}
+		}
+
+		certRules := make([]auth.CertRule, len(srvCfg.Auth.ClientCerts))
+		for i, c := range srvCfg.Auth.ClientCerts {
+			certRules[i] = auth.CertRule{OU: c.OU, CN: c.CN, Roles: c.Roles}
+		}
+
+		authen, err = auth.New(auth.Config{
+			Issuer:         srvCfg.Auth.Issuer,
+			Audience:       srvCfg.Auth.Audience,
+			HMACSecretFile: srvCfg.Auth.HMACSecretFile,
+			PublicKeyFiles: srvCfg.Auth.PublicKeyFiles,
+			JWKSFile:       srvCfg.Auth.JWKSFile,
+			APIKeys:        apiKeys,
+			ClientCerts:    certRules,
+		})
+		if err != nil {
+			return fmt.Errorf("initializing authentication: %w", err)
+		}
+
+		policy, err = auth.NewPolicy(srvCfg.Auth.Policies)
+		if err != nil {
+			return fmt.Errorf("initializing authorization: %w", err)
+		}
+	}
+
+	// -------------------------------------------------------------------
+	// New Channels
+	// -------------------------------------------------------------------
+
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	// Use a buffered channel because the signal package requires it.
+	// The same channel is handed to the API so integrity errors can trigger
+	// a graceful shutdown.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
 	// -------------------------------------------------------------------
 	// Initialize API
 	// -------------------------------------------------------------------
 	log.Info("startup.api", "status", "initializing API")
 
+	// Draining is flipped once shutdown starts so readiness checks fail
+	// and the orchestrator stops routing traffic to this instance.
+	draining := &atomic.Bool{}
+
 	apiMux := handlers.APIMux(handlers.APIMuxConfig{
-		Env:     srvCfg.App.Env,
-		Log:     log,
-		DB:      db,
-		RWMux:   rwmux,
-		Headers: srvCfg.App.EnforceHeaders,
+		Env:            srvCfg.App.Env,
+		Build:          appVersionLDFlag,
+		BuildTime:      appBuildTimestampLDFlag,
+		StartedOn:      startedOn,
+		Shutdown:       shutdown,
+		Draining:       draining,
+		Log:            log,
+		DB:             db,
+		Headers:        srvCfg.App.EnforceHeaders,
+		RequireIfMatch: srvCfg.App.RequireIfMatch,
+		ProblemDetails: srvCfg.App.ProblemDetails,
+		Auth:           authen,
+		Policy:         policy,
 	})
 
 	// -------------------------------------------------------------------
-	// New Channels
+	// Starting the Scheduler
 	// -------------------------------------------------------------------
 
-	// Make a channel to listen for an interrupt or terminate signal from the OS.
-	// Use a buffered channel because the signal package requires it.
-	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	// Changes recorded ahead of their effective date are applied in the
+	// background. Every instance may run it, due changes are shared out.
+	if interval := srvCfg.App.PendingChangeInterval; interval > 0 {
+		log.Info("startup.scheduler", "status", "scheduler started", "interval", interval)
+
+		schedCtx, schedCancel := context.WithCancel(context.Background())
+		schedDone := make(chan struct{})
+		go func() {
+			defer close(schedDone)
+			scheduler.Run(schedCtx, scheduler.Config{
+				Log:      log,
+				Employee: employee.NewCore(log, db),
+				Interval: interval,
+			})
+		}()
+		defer func() {
+			log.Info("shutdown", "status", "stopping scheduler")
+			schedCancel()
+			<-schedDone
+		}()
+	}
 
 	// Make a channel to listen for errors coming from the listener. Use a
 	// buffered channel so the goroutine can exit if we don't collect this error.
@@ -187,10 +316,25 @@ func run(log *slog.Logger) error {
 		IdleTimeout:       srvCfg.Web.IdleTimeout,
 		MaxHeaderBytes:    srvCfg.Web.MaxHeaderBytes,
 	}
+	adminHost := fmt.Sprintf("%s:%s", srvCfg.Web.AdminHost, srvCfg.Web.AdminPort)
+	admin := http.Server{
+		Addr:              adminHost,
+		Handler:           handlers.AdminMux(),
+		ReadHeaderTimeout: srvCfg.Web.ReadHeaderTimeout,
+		ReadTimeout:       srvCfg.Web.ReadTimeout,
+		WriteTimeout:      srvCfg.Web.WriteTimeout,
+		IdleTimeout:       srvCfg.Web.IdleTimeout,
+		MaxHeaderBytes:    srvCfg.Web.MaxHeaderBytes,
+	}
+	if srvCfg.App.TLS {
+		api.TLSConfig, err = serverTLSConfig(srvCfg.Web.TLS)
+		if err != nil {
+			return fmt.Errorf("configuring tls: %w", err)
+		}
+	}
 	// TODO: Push this in with a new Interface for a logger w/ io.Writer
 	// https://stackoverflow.com/questions/52294334/net-http-set-custom-logger
 	//api.ErrorLog = stdLibLog.New(log, "", 0)
-	//api.TLSConfig.BuildNameToCertificate()
 
 	// -------------------------------------------------------------------
 	// Starting the API
@@ -198,13 +342,31 @@ func run(log *slog.Logger) error {
 
 	// Start the service listening for api requests.
 	go func() {
-		log.Info("startup.api", "status", "api router started", "host", api.Addr)
+		log.Info("startup.api", "status", "api router started", "host", api.Addr, "tls", srvCfg.App.TLS)
 
 		log.Debug("API STARTED", "host", api.Addr)
 
+		if srvCfg.App.TLS {
+			serverErrors <- api.ListenAndServeTLS(srvCfg.Web.TLS.CertFile, srvCfg.Web.TLS.KeyFile)
+			return
+		}
 		serverErrors <- api.ListenAndServe()
 	}()
 
+	// Start the service listening for admin requests. It is closed once the
+	// api has been shut down.
+	go func() {
+		log.Info("startup.admin", "status", "admin router started", "host", admin.Addr)
+
+		if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErrors <- fmt.Errorf("admin: %w", err)
+		}
+	}()
+	defer func() {
+		log.Info("shutdown", "status", "stopping admin router", "host", admin.Addr)
+		_ = admin.Close()
+	}()
+
 	// -------------------------------------------------------------------
 	// Shutdown
 	// -------------------------------------------------------------------
@@ -222,6 +384,14 @@ func run(log *slog.Logger) error {
 		log.Info("shutdown", "status", "shutdown started", "signal", sig)
 		defer log.Info("shutdown", "status", "shutdown completed", "signal", sig)
 
+		// Fail readiness checks and keep serving until the load balancers
+		// have noticed and stopped routing new requests here.
+		draining.Store(true)
+		if srvCfg.Web.DrainPeriod > 0 {
+			log.Info("shutdown", "status", "draining", "period", srvCfg.Web.DrainPeriod)
+			time.Sleep(srvCfg.Web.DrainPeriod)
+		}
+
 		// Give outstanding requests a deadline for completion.
 		ctx, cancel := context.WithTimeout(context.Background(), srvCfg.Web.ShutdownTimeout)
 		defer cancel()
@@ -235,3 +405,39 @@ func run(log *slog.Logger) error {
 
 	return nil
 }
+
+// serverTLSConfig builds the TLS configuration of the api server. Client
+// certificates are verified against the CA bundle when they are requested.
+func serverTLSConfig(cfg config.TLS) (*tls.Config, error) {
+	tlsCfg := tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	switch strings.ToLower(cfg.ClientAuth) {
+	case "", "none":
+		tlsCfg.ClientAuth = tls.NoClientCert
+	case "request":
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth %q", cfg.ClientAuth)
+	}
+
+	if tlsCfg.ClientAuth != tls.NoClientCert {
+		if cfg.CAFile == "" {
+			return nil, errors.New("a ca file is required to verify client certificates")
+		}
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.ClientCAs = pool
+	}
+
+	return &tlsCfg, nil
+}
@@ -0,0 +1,239 @@
+// Package db for database functions
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// Store holds details for basic database needs
+type Store struct {
+	log          *slog.Logger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *slog.Logger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passes function and do commit/rollback at the end. The
+// options set the isolation level, nil uses the database default.
+func (s Store) WithinTran(ctx context.Context, opts *sql.TxOptions, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, opts, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// -----------------------------------------------------------------------
+// Database Query Repository
+// -----------------------------------------------------------------------
+
+// Create inserts a new department into the database.
+func (s Store) Create(ctx context.Context, rs Department) (database.DBResults, error) {
+	const q = `
+	INSERT INTO department
+		(name, description, created_on, updated_on)
+	VALUES
+		(:name, :description, :created_on, :updated_on)`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, rs)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("inserting department: %w", err)
+	}
+
+	return res, nil
+}
+
+// Update replaces a department record in the database.
+func (s Store) Update(ctx context.Context, rs Department) (database.DBResults, error) {
+	const q = `
+	UPDATE
+		department
+	SET
+		name = :name,
+		description = :description,
+		updated_on = :updated_on
+	WHERE
+		id = :id`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, rs)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("updating department ID[%s]: %w", rs.ID, err)
+	}
+
+	return res, nil
+}
+
+// Delete removes a department from the database.
+func (s Store) Delete(ctx context.Context, id string, now time.Time) (database.DBResults, error) {
+	data := struct {
+		ID        string    `db:"id"`
+		DeletedOn time.Time `db:"deleted_on"`
+	}{
+		ID:        id,
+		DeletedOn: now,
+	}
+
+	const q = `
+	UPDATE
+		department
+	SET
+		deleted_on = :deleted_on
+	WHERE
+		id = :id`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("deleting department id[%s]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// Query retrieves a list of existing departments from the database.
+func (s Store) Query(ctx context.Context, pagi database.Pagination) ([]Department, error) {
+	q := database.PaginationQuery(pagi, `
+	SELECT
+		id,
+		name,
+		description,
+		created_on,
+		updated_on,
+		deleted_on
+	FROM
+		department
+	WHERE
+		deleted_on is null
+	ORDER BY
+		:sort :direction,
+		id :direction
+	LIMIT
+		:page,:per_page`)
+
+	// Slice to hold results
+	var res []Department
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, pagi, &res); err != nil {
+		return nil, fmt.Errorf("selecting department: %w", err)
+	}
+
+	return res, nil
+}
+
+// QueryByID retrieves a single department from the database.
+func (s Store) QueryByID(ctx context.Context, id string) (Department, error) {
+	return s.queryByID(ctx, id, "")
+}
+
+// QueryByIDForUpdate retrieves the record like QueryByID and locks it until
+// the end of the transaction, so it cannot change between being read and
+// written.
+func (s Store) QueryByIDForUpdate(ctx context.Context, id string) (Department, error) {
+	return s.queryByID(ctx, id, "FOR UPDATE")
+}
+
+// queryByID selects the record by id, lock is appended to the query.
+func (s Store) queryByID(ctx context.Context, id string, lock string) (Department, error) {
+	data := struct {
+		ID string `db:"id"`
+	}{ID: id}
+
+	q := `
+	SELECT
+		id,
+		name,
+		description,
+		created_on,
+		updated_on,
+		deleted_on
+	FROM
+		department
+	WHERE
+		id = :id
+		and deleted_on is null
+	` + lock
+
+	var res Department
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Department{}, fmt.Errorf("selecting by id[%q]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// CountActiveEmployees returns the number of employees, not soft deleted,
+// assigned to the department.
+func (s Store) CountActiveEmployees(ctx context.Context, id string) (int, error) {
+	data := struct {
+		ID string `db:"id"`
+	}{ID: id}
+
+	const q = `
+	SELECT
+		count(*) as total
+	FROM
+		employee
+	WHERE
+		department_id = :id
+		and deleted_on is null`
+
+	var res struct {
+		Total int `db:"total"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return 0, fmt.Errorf("counting employees of department id[%q]: %w", id, err)
+	}
+
+	return res.Total, nil
+}
+
+// UnDelete restores a deleted department from the database.
+func (s Store) UnDelete(ctx context.Context, id string, now time.Time) (database.DBResults, error) {
+	data := struct {
+		ID        string    `db:"id"`
+		UpdatedOn time.Time `db:"updated_on"`
+	}{
+		ID:        id,
+		UpdatedOn: now,
+	}
+
+	const q = `
+	UPDATE
+		department
+	SET
+		updated_on = :updated_on,
+		deleted_on = null
+	WHERE
+		id = :id`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("restoring department id[%s]: %w", id, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,16 @@
+package db
+
+import (
+	"time"
+)
+
+// Department represent the structure we need for moving data
+// between the app and the database.
+type Department struct {
+	ID          string     `db:"id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	CreatedOn   time.Time  `db:"created_on"`
+	UpdatedOn   time.Time  `db:"updated_on"`
+	DeletedOn   *time.Time `db:"deleted_on"`
+}
@@ -0,0 +1,200 @@
+// Package department for department handler functions
+package department
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/department/db"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("department not found")
+	ErrInvalidID    = errors.New("ID is not in its proper form")
+	ErrHasEmployees = errors.New("department still has active employees")
+)
+
+// Core manages the set of APIs for department access
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for department api access.
+func NewCore(log *slog.Logger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// -----------------------------------------------------------------------
+// CRUD Methods
+// -----------------------------------------------------------------------
+
+// Create inserts a new department into the database
+func (c Core) Create(ctx context.Context, nd NewDepartment, now time.Time) (Department, error) {
+	if err := validate.Check(nd); err != nil {
+		return Department{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbRS := db.Department{
+		Name:        strings.TrimSpace(nd.Name),
+		Description: strings.TrimSpace(nd.Description),
+		CreatedOn:   now,
+		UpdatedOn:   now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		res, err := c.store.Tran(tx).Create(ctx, dbRS)
+		if err != nil {
+			return err
+		}
+		dbRS.ID = fmt.Sprintf("%d", res.LastInsertID)
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return Department{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toDepartment(dbRS), nil
+}
+
+// Update replaces a department document in the database.
+func (c Core) Update(ctx context.Context, id string, ud UpdateDepartment, now time.Time) error {
+	if err := validate.Check(ud); err != nil {
+		return err
+	}
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	// The record is locked from the read to the write so concurrent
+	// updates cannot overwrite each other.
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbRS, err := store.QueryByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("updating department id[%s]: %w", id, err)
+		}
+
+		isEmpty := true
+		if ud.Name != nil {
+			dbRS.Name = strings.TrimSpace(*ud.Name)
+			isEmpty = false
+		}
+		if ud.Description != nil {
+			dbRS.Description = strings.TrimSpace(*ud.Description)
+			isEmpty = false
+		}
+		// No changes were made - don't touch the DB
+		if isEmpty {
+			return nil
+		}
+		dbRS.UpdatedOn = now
+
+		if _, err := store.Update(ctx, dbRS); err != nil {
+			return fmt.Errorf("update id[%s]: %w", id, err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a department from the database. Departments that still
+// have active employees cannot be deleted. The transaction is serializable
+// so no employee can be assigned between the count and the delete.
+func (c Core) Delete(ctx context.Context, id string, now time.Time) error {
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if _, err := store.QueryByIDForUpdate(ctx, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("deleting department id[%s]: %w", id, err)
+		}
+
+		total, err := store.CountActiveEmployees(ctx, id)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return ErrHasEmployees
+		}
+
+		if _, err := store.Delete(ctx, id, now); err != nil {
+			return fmt.Errorf("delete id[%s]: %w", id, err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing records from the database
+func (c Core) Query(ctx context.Context, pagi database.Pagination) ([]Department, error) {
+	res, err := c.store.Query(ctx, pagi)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toDepartmentSlice(res), nil
+}
+
+// QueryByID retrieves a single records from the database by id
+func (c Core) QueryByID(ctx context.Context, id string) (Department, error) {
+	if err := validate.CheckID(id); err != nil {
+		return Department{}, ErrInvalidID
+	}
+
+	res, err := c.store.QueryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Department{}, ErrNotFound
+		}
+		return Department{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toDepartment(res), nil
+}
+
+// UnDelete restore a deleted department from the database.
+func (c Core) UnDelete(ctx context.Context, id string, now time.Time) error {
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	_, err := c.store.UnDelete(ctx, id, now)
+	if err != nil {
+		return fmt.Errorf("department id[%s]: %w", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package department_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/department"
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/pkg/database/dbtest"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+type TestSuite struct {
+	db       *sqlx.DB
+	log      *slog.Logger
+	teardown func()
+
+	tableNames []string
+	ctx        context.Context
+}
+
+var ts TestSuite
+
+// TableNames are copied into test_db, employees are needed to check that
+// departments in use cannot be deleted.
+var TableNames = []string{"position", "department", "employee", "employee_audit"}
+
+func TestMain(m *testing.M) {
+	success := m.Run()
+	ts.teardown()
+	os.Exit(success)
+}
+
+func setupTestDB() error {
+	for _, tableName := range ts.tableNames {
+		// Make sure we have sufficient permission for the db user
+		q := fmt.Sprintf("create table if not exists test_db.%s like %s.%s", tableName, dbtest.UnitDbConfig.Name, tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("creating test_db.%s test table: %v", tableName, err)
+		}
+
+		q = fmt.Sprintf("truncate table test_db.%s", tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("truncating test_db.%s test table: %v", tableName, err)
+		}
+
+		q = fmt.Sprintf(`
+			insert into test_db.%s
+			select * from %s.%s
+			order by created_on desc limit 100`,
+			tableName,
+			dbtest.UnitDbConfig.Name,
+			tableName,
+		)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("error copying data to test_db.%s test table: %v", tableName, err)
+		}
+	}
+
+	return nil
+}
+
+func registerTestSuite(t *testing.T) {
+	if ts.db == nil {
+		log, db, teardown := dbtest.NewUnit(t)
+		ctx := context.Background()
+		ts = TestSuite{
+			db:         db,
+			log:        log,
+			teardown:   teardown,
+			ctx:        ctx,
+			tableNames: TableNames,
+		}
+
+		t.Logf("Create test database tables %v", ts.tableNames)
+		if err := setupTestDB(); err != nil {
+			t.Fatalf("Failed to create test tables: %v", err)
+		}
+	}
+}
+
+func Test_DepartmentDelete(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	dc := department.NewCore(ts.log, ts.db)
+	ec := employee.NewCore(ts.log, ts.db)
+
+	// hard coded for easy testing
+	now := time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Log("Given the need to delete Departments")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen deleting a Department with or without Employees.", testID)
+		{
+			// Each case gets its own department with active employees still
+			// assigned and removed employees that were assigned then deleted.
+			// A department with active employees answers 409 in the API.
+			cases := []struct {
+				name     string
+				id       string
+				active   int
+				removed  int
+				expected error
+			}{
+				{
+					name: "without employees",
+				},
+				{
+					name:     "with an active employee",
+					active:   1,
+					expected: department.ErrHasEmployees,
+				},
+				{
+					name:     "with active and deleted employees",
+					active:   2,
+					removed:  1,
+					expected: department.ErrHasEmployees,
+				},
+				{
+					name:    "with deleted employees only",
+					removed: 2,
+				},
+				{
+					name: "with a sequential id",
+				},
+				{
+					name:     "with an unknown id",
+					id:       "923498273",
+					expected: department.ErrNotFound,
+				},
+				{
+					name:     "with an invalid id",
+					id:       "01906a4e-8c2b-7b3e-9f4a-2d5c6e7f8a9b",
+					expected: department.ErrInvalidID,
+				},
+			}
+
+			var nd department.NewDepartment
+			data := nd.GenerateFakeData(len(cases))
+
+			for i, tc := range cases {
+				dep, err := dc.Create(ts.ctx, data[i], now)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\t%s : should be able to create Department : %s", dbtest.Failed, testID, tc.name, err)
+				}
+
+				for n := 0; n < tc.active+tc.removed; n++ {
+					emp, err := ec.Create(ts.ctx, employee.NewEmployee{Name: "Sachin Prasad", DepartmentID: &dep.ID}, now)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\t%s : should be able to assign an Employee : %s", dbtest.Failed, testID, tc.name, err)
+					}
+					if n < tc.removed {
+						if err := ec.Delete(ts.ctx, emp.ID, nil, now); err != nil {
+							t.Fatalf("\t%s\tTest %d:\t%s : should be able to delete an Employee : %s", dbtest.Failed, testID, tc.name, err)
+						}
+					}
+				}
+
+				id := dep.ID
+				if tc.id != "" {
+					id = tc.id
+				} else if err := validate.CheckID(id); err != nil {
+					t.Fatalf("\t%s\tTest %d:\t%s : Department should have a sequential id : %q", dbtest.Failed, testID, tc.name, id)
+				}
+
+				err = dc.Delete(ts.ctx, id, now)
+				if !errors.Is(err, tc.expected) {
+					t.Fatalf("\t%s\tTest %d:\tDelete %s : expected %v, got %v", dbtest.Failed, testID, tc.name, tc.expected, err)
+				}
+
+				// A refused delete leaves the department in place.
+				_, err = dc.QueryByID(ts.ctx, dep.ID)
+				switch {
+				case tc.expected == nil && !errors.Is(err, department.ErrNotFound):
+					t.Fatalf("\t%s\tTest %d:\tDelete %s : Department should be gone, got %v", dbtest.Failed, testID, tc.name, err)
+				case tc.expected != nil && err != nil:
+					t.Fatalf("\t%s\tTest %d:\tDelete %s : Department should remain : %s", dbtest.Failed, testID, tc.name, err)
+				}
+				t.Logf("\t%s\tTest %d:\tDelete %s", dbtest.Success, testID, tc.name)
+				testID++
+			}
+		}
+	}
+}
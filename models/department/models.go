@@ -0,0 +1,120 @@
+package department
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pansachin/employee-service/models/department/db"
+)
+
+// Department holds the department information.
+//
+//swagger:model Department
+type Department struct {
+	// Primary Key
+	// example: 1
+	ID string `json:"id"`
+	// Department Name
+	// example: Engineering
+	Name string `json:"name"`
+	// Department description
+	// example: Builds and runs the products
+	Description string `json:"description"`
+	// Database created value
+	// example: 2021-05-25T00:53:16.535668Z
+	CreatedOn time.Time `json:"created_on"`
+	// Database last updated value
+	// example: 2021-05-25T00:53:16.535668Z
+	UpdatedOn time.Time `json:"updated_on"`
+	// Database soft delete value
+	// example: 2021-05-25T00:53:16.535668Z
+	// swagger:ignore
+	DeletedOn *time.Time `json:"deleted_on,omitempty"`
+}
+
+// NewDepartment defines the model of adding new department.
+//
+//swagger:model NewDepartment
+type NewDepartment struct {
+	// Name of the department
+	// in: string
+	// required: true
+	// example: Engineering
+	Name string `json:"name" validate:"required,notblank,max=56"`
+	// Department description
+	// in: string
+	// example: Builds and runs the products
+	Description string `json:"description" validate:"max=256"`
+}
+
+// UpdateDepartment defines what information may be provided to
+// modify an existing Department. All fields are optional
+// so clients can send just the fields they want changed.
+//
+//swagger:model UpdateDepartment
+type UpdateDepartment struct {
+	// Name of the department
+	// in: string
+	// example: Platform Engineering
+	Name *string `json:"name" validate:"omitempty,notblank,max=56"`
+	// Department description
+	// in: string
+	// example: Builds and runs the platform
+	Description *string `json:"description" validate:"omitempty,max=256"`
+}
+
+// =============================================================================
+
+func toDepartment(dbRS db.Department) Department {
+	return Department{
+		ID:          dbRS.ID,
+		Name:        dbRS.Name,
+		Description: dbRS.Description,
+		CreatedOn:   dbRS.CreatedOn,
+		UpdatedOn:   dbRS.UpdatedOn,
+		DeletedOn:   dbRS.DeletedOn,
+	}
+}
+
+func toDepartmentSlice(dbRSs []db.Department) []Department {
+	rs := make([]Department, len(dbRSs))
+	for i, dbRS := range dbRSs {
+		rs[i] = toDepartment(dbRS)
+	}
+	return rs
+}
+
+//------------------------------------------------------------------------
+// Fake data generators
+//------------------------------------------------------------------------
+
+// GenerateFakeData return an array for NewDepartments
+func (nd NewDepartment) GenerateFakeData(num int) []NewDepartment {
+	var data []NewDepartment
+	for i := 0; i < num; i++ {
+		data = append(data, nd.fakeData(i+1))
+	}
+	return data
+}
+
+// fakeData creates the fake record. Names are unique so the records can be
+// inserted repeatedly.
+func (nd NewDepartment) fakeData(counter int) NewDepartment {
+	return NewDepartment{
+		Name:        fmt.Sprintf("Department %d-%d", time.Now().UnixNano()%1000000000, counter),
+		Description: "Generated department",
+	}
+}
+
+// Seed runs create methods from an array of new values
+func (c Core) Seed(ctx context.Context, data []NewDepartment) error {
+	now := time.Now().UTC()
+	for _, nd := range data {
+		if _, err := c.Create(ctx, nd, now); err != nil {
+			return fmt.Errorf("error seeding department: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package employee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// QueryByIDAsOf retrieves the employee as it was at the instant, built from
+// the recorded history. ErrNotFound is returned if the employee did not
+// exist or was deleted at that time.
+func (c Core) QueryByIDAsOf(ctx context.Context, id string, asOf time.Time) (Employee, error) {
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return Employee{}, err
+	}
+
+	res, err := c.store.QueryByIDAsOf(ctx, id, asOf)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Employee{}, ErrNotFound
+		}
+		return Employee{}, fmt.Errorf("query: %w", err)
+	}
+	if res.DeletedOn != nil {
+		return Employee{}, ErrNotFound
+	}
+
+	return toEmployee(res), nil
+}
+
+// QueryAsOf retrieves a page of the employees as they were at the instant,
+// filtered and sorted like Query, together with the number of matching
+// employees. Employees deleted since are included, the ones already
+// deleted at that time only with filter.IncludeDeleted. Pages are
+// addressed by number, cursors are not supported.
+func (c Core) QueryAsOf(ctx context.Context, filter QueryFilter, pagi database.Pagination, asOf time.Time) ([]Employee, int, error) {
+	if filter.DepartmentID != nil {
+		if err := validate.CheckID(*filter.DepartmentID); err != nil {
+			return nil, 0, ErrInvalidDepartment
+		}
+	}
+
+	res, err := c.store.QueryAsOf(ctx, toDBQueryFilter(filter), pagi, asOf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query: %w", err)
+	}
+
+	total, err := c.store.CountAsOf(ctx, toDBQueryFilter(filter), asOf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count: %w", err)
+	}
+
+	return toEmployeeSlice(res), total, nil
+}
@@ -0,0 +1,170 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// Set of actions recorded in the history of an employee.
+const (
+	ChangeCreate   = "create"
+	ChangeUpdate   = "update"
+	ChangeDelete   = "delete"
+	ChangeUndelete = "undelete"
+)
+
+// Change is an entry in the history of an employee.
+//
+//swagger:model Change
+type Change struct {
+	// Primary Key
+	// example: 12
+	ID string `json:"id"`
+	// Employee changed
+	// example: 1
+	EmployeeID string `json:"employee_id"`
+	// What was done, one of create, update, delete or undelete
+	// example: update
+	Action string `json:"action"`
+	// Caller making the change
+	// example: jane.doe
+	Actor string `json:"actor"`
+	// Trace ID of the request making the change
+	// example: 0f4b7c1e-2d3a-4b5c-8d9e-0a1b2c3d4e5f
+	TraceID string `json:"trace_id"`
+	// The employee before the change, null on create
+	Before json.RawMessage `json:"before"`
+	// The employee after the change
+	After json.RawMessage `json:"after"`
+	// The fields changed, each with its value before and after
+	// example: {"position": {"from": "Engineer", "to": "Staff Engineer"}}
+	Diff json.RawMessage `json:"diff"`
+	// When the change was made
+	// example: 2021-05-25T00:53:16.535668Z
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// FieldChange is the value of a field before and after a change.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// History retrieves a page of the changes made to the employee, deleted
+// employees included. Pages are addressed by number, sort and cursor are
+// ignored.
+func (c Core) History(ctx context.Context, id string, pagi database.Pagination) ([]Change, int, error) {
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if _, err := c.store.QueryByIDWithDeleted(ctx, id); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, 0, ErrNotFound
+		}
+		return nil, 0, fmt.Errorf("query: employee id[%s]: %w", id, err)
+	}
+
+	pagi.Cursor = nil
+	res, err := c.store.QueryAudit(ctx, id, pagi)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query: %w", err)
+	}
+
+	total, err := c.store.CountAudit(ctx, id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count: %w", err)
+	}
+
+	changes := make([]Change, len(res))
+	for i, dbA := range res {
+		changes[i] = toChange(dbA)
+	}
+
+	return changes, total, nil
+}
+
+func toChange(dbA db.Audit) Change {
+	return Change{
+		ID:         dbA.ID,
+		EmployeeID: dbA.EmployeeID,
+		Action:     dbA.Action,
+		Actor:      dbA.Actor,
+		TraceID:    dbA.TraceID,
+		Before:     dbA.Before,
+		After:      dbA.After,
+		Diff:       dbA.Diff,
+		CreatedOn:  dbA.CreatedOn,
+	}
+}
+
+// audit records the change of the employee from before to after in the
+// transaction of the store, so the history cannot miss a change nor hold
+// one that was rolled back. A nil before is a creation.
+func (c Core) audit(ctx context.Context, store db.Store, action string, before *db.Employee, after db.Employee, now time.Time) error {
+	a := db.Audit{
+		EmployeeID: after.ID,
+		Action:     action,
+		Actor:      api.GetPrincipal(ctx).Subject,
+		TraceID:    api.GetTracerUID(ctx),
+		CreatedOn:  now,
+	}
+
+	var err error
+	if a.After, err = json.Marshal(toEmployee(after)); err != nil {
+		return fmt.Errorf("encoding employee: %w", err)
+	}
+	if before != nil {
+		if a.Before, err = json.Marshal(toEmployee(*before)); err != nil {
+			return fmt.Errorf("encoding employee: %w", err)
+		}
+	}
+	if a.Diff, err = diff(a.Before, a.After); err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	if _, err := store.CreateAudit(ctx, a); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// diff returns the fields of the encoded employees that differ, by their
+// JSON name. The version and the update time change with every write and
+// are left out.
+func diff(before json.RawMessage, after json.RawMessage) (json.RawMessage, error) {
+	from := make(map[string]interface{})
+	if before != nil {
+		if err := json.Unmarshal(before, &from); err != nil {
+			return nil, err
+		}
+	}
+	to := make(map[string]interface{})
+	if err := json.Unmarshal(after, &to); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]FieldChange)
+	for _, m := range []map[string]interface{}{from, to} {
+		for k := range m {
+			if k == "version" || k == "updated_on" {
+				continue
+			}
+			if !reflect.DeepEqual(from[k], to[k]) {
+				fields[k] = FieldChange{From: from[k], To: to[k]}
+			}
+		}
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,220 @@
+package employee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// MaxBatch is the largest number of operations in a batch.
+const MaxBatch = 100
+
+// Set of operations of a batch.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Batch is a list of employee operations sent in a single call.
+//
+//swagger:model Batch
+type Batch struct {
+	// Apply every operation or none of them. Without it every operation
+	// is applied on its own and reported separately.
+	// example: true
+	Atomic bool `json:"atomic"`
+	// The operations, applied in order
+	// required: true
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one operation of a batch.
+//
+//swagger:model BatchOperation
+type BatchOperation struct {
+	// One of create, update or delete
+	// required: true
+	// example: create
+	Op string `json:"op"`
+	// Employee ID or public ID, for update and delete
+	// example: 3
+	ID string `json:"id,omitempty"`
+	// Version the update or delete is based on, like If-Match
+	// example: 2
+	Version *int `json:"version,omitempty"`
+	// The employee to create
+	Employee *NewEmployee `json:"employee,omitempty"`
+	// The changes to apply on update
+	Changes *UpdateEmployee `json:"changes,omitempty"`
+}
+
+// BatchResult is the outcome of one operation of a batch. Employee holds
+// the employee created or updated, Err why the operation failed.
+type BatchResult struct {
+	Employee *Employee
+	Err      error
+}
+
+// BatchError reports the operation an atomic batch failed on.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error implements the error interface.
+func (be *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", be.Index, be.Err)
+}
+
+// Unwrap returns the error of the operation.
+func (be *BatchError) Unwrap() error {
+	return be.Err
+}
+
+// Batch applies the operations in order. An atomic batch runs them in a
+// single transaction and stops at the first failure, returned as a
+// *BatchError, with nothing applied. Otherwise every operation is applied
+// in its own transaction and its failure is reported in its result.
+func (c Core) Batch(ctx context.Context, b Batch, now time.Time) ([]BatchResult, error) {
+	if err := checkBatch(b); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(b.Operations))
+
+	if b.Atomic {
+		tran := func(tx sqlx.ExtContext) error {
+			store := c.store.Tran(tx)
+			for i, op := range b.Operations {
+				emp, err := c.applyOperation(ctx, store, op, now)
+				if err != nil {
+					return &BatchError{Index: i, Err: err}
+				}
+				results[i] = BatchResult{Employee: emp}
+			}
+			return nil
+		}
+
+		if err := c.store.WithinTran(ctx, serializable, tran); err != nil {
+			var be *BatchError
+			if errors.As(err, &be) {
+				return nil, be
+			}
+			return nil, fmt.Errorf("tran: %w", err)
+		}
+
+		return results, nil
+	}
+
+	for i, op := range b.Operations {
+		var res BatchResult
+		tran := func(tx sqlx.ExtContext) error {
+			emp, err := c.applyOperation(ctx, c.store.Tran(tx), op, now)
+			res = BatchResult{Employee: emp, Err: err}
+			return err
+		}
+
+		// The transaction can also fail to begin or to commit, the
+		// operation is then not applied whatever it reported.
+		if err := c.store.WithinTran(ctx, serializable, tran); err != nil && res.Err == nil {
+			res = BatchResult{Err: fmt.Errorf("tran: %w", err)}
+		}
+		results[i] = res
+	}
+
+	return results, nil
+}
+
+// applyOperation runs the operation in the transaction of the store, it
+// returns the employee created or updated.
+func (c Core) applyOperation(ctx context.Context, store db.Store, op BatchOperation, now time.Time) (*Employee, error) {
+	if op.Op == BatchCreate {
+		if err := validate.Check(*op.Employee); err != nil {
+			return nil, err
+		}
+		emp, err := c.create(ctx, store, *op.Employee, now)
+		if err != nil {
+			return nil, err
+		}
+		return &emp, nil
+	}
+
+	id, err := c.resolveID(ctx, op.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var match Match
+	if op.Version != nil {
+		match = MatchVersion(*op.Version)
+	}
+
+	if op.Op == BatchDelete {
+		return nil, c.delete(ctx, store, id, match, now)
+	}
+
+	if err := validate.Check(*op.Changes); err != nil {
+		return nil, err
+	}
+	dbRS, err := c.update(ctx, store, id, *op.Changes, match, now)
+	if err != nil {
+		return nil, err
+	}
+	emp := toEmployee(dbRS)
+
+	return &emp, nil
+}
+
+// checkBatch validates the shape of the batch before anything is applied,
+// every problem is reported with the index of its operation.
+func checkBatch(b Batch) error {
+	var fields validate.FieldErrors
+	fieldErr := func(field string, msg string) {
+		fields.FieldError = append(fields.FieldError, validate.FieldError{Field: field, Error: msg})
+	}
+
+	switch {
+	case len(b.Operations) == 0:
+		fieldErr("operations", "at least one operation is required")
+	case len(b.Operations) > MaxBatch:
+		fieldErr("operations", fmt.Sprintf("at most %d operations are allowed", MaxBatch))
+	}
+
+	for i, op := range b.Operations {
+		prefix := fmt.Sprintf("operations[%d].", i)
+		switch op.Op {
+		case BatchCreate:
+			if op.Employee == nil {
+				fieldErr(prefix+"employee", "employee is required to create")
+			}
+		case BatchUpdate:
+			if op.ID == "" {
+				fieldErr(prefix+"id", "id is required to update")
+			}
+			if op.Changes == nil {
+				fieldErr(prefix+"changes", "changes are required to update")
+			} else if op.Changes.EffectiveOn != nil {
+				fieldErr(prefix+"changes.effective_on", "effective_on is not supported in a batch")
+			}
+		case BatchDelete:
+			if op.ID == "" {
+				fieldErr(prefix+"id", "id is required to delete")
+			}
+		default:
+			fieldErr(prefix+"op", "op must be one of create, update or delete")
+		}
+	}
+
+	if len(fields.FieldError) > 0 {
+		return fields
+	}
+
+	return nil
+}
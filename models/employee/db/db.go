@@ -3,11 +3,12 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"slices"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -20,30 +21,25 @@ type Store struct {
 	log          *slog.Logger
 	tr           database.Transactor
 	db           sqlx.ExtContext
-	rwmux        *sync.RWMutex
 	isWithinTran bool
 }
 
 // NewStore constructs a data for api access.
-func NewStore(log *slog.Logger, db *sqlx.DB, rwmux *sync.RWMutex) Store {
+func NewStore(log *slog.Logger, db *sqlx.DB) Store {
 	return Store{
-		log:   log,
-		tr:    db,
-		db:    db,
-		rwmux: rwmux,
+		log: log,
+		tr:  db,
+		db:  db,
 	}
 }
 
-// WithinTran runs passes function and do commit/rollback at the end.
-func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+// WithinTran runs passes function and do commit/rollback at the end. The
+// options set the isolation level, nil uses the database default.
+func (s Store) WithinTran(ctx context.Context, opts *sql.TxOptions, fn func(sqlx.ExtContext) error) error {
 	if s.isWithinTran {
 		return fn(s.db)
 	}
-	s.rwmux.Lock()
-	err := database.WithinTran(ctx, s.log, s.tr, fn)
-	s.rwmux.Unlock()
-
-	return err
+	return database.WithinTran(ctx, s.log, s.tr, opts, fn)
 }
 
 // Tran return new Store with transaction in it.
@@ -64,51 +60,57 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 func (s Store) Create(ctx context.Context, rs Employee) (database.DBResults, error) {
 	const q = `
 	INSERT INTO employee
-		(name, position, created_on, updated_on)
+		(public_id, name, position_id, department_id, manager_id, version, created_on, updated_on)
 	VALUES
-		(:name, :position, :created_on, :updated_on)`
+		(:public_id, :name, :position_id, :department_id, :manager_id, :version, :created_on, :updated_on)`
 
 	res, err := database.NamedExecContext(ctx, s.log, s.db, q, rs)
 	if err != nil {
-		if strings.Contains(err.Error(), "Duplicate entry") {
-			return database.DBResults{}, database.NewError(database.ErrDBDuplicatedEntry, http.StatusConflict)
-		}
 		return database.DBResults{}, fmt.Errorf("inserting employee: %w", err)
 	}
 
 	return res, nil
 }
 
-// Update replaces a employee record in the database.
+// Update replaces a employee record in the database. The record is only
+// written if it is still at the version it was read at, otherwise
+// database.ErrDBVersionConflict is returned.
 func (s Store) Update(ctx context.Context, rs Employee) (database.DBResults, error) {
 	const q = `
 	UPDATE
 		employee
 	SET 
 		name = :name,
-		position = :position,
+		position_id = :position_id,
+		department_id = :department_id,
+		manager_id = :manager_id,
+		version = version + 1,
 		updated_on = :updated_on
 	WHERE
-		id = :id`
+		id = :id
+		and version = :version`
 
 	res, err := database.NamedExecContext(ctx, s.log, s.db, q, rs)
 	if err != nil {
-		if strings.Contains(err.Error(), "Duplicate entry") {
-			return database.DBResults{}, database.NewError(database.ErrDBDuplicatedEntry, http.StatusConflict)
-		}
 		return database.DBResults{}, fmt.Errorf("updating Employee ID[%s]: %w", rs.ID, err)
 	}
+	if res.AffectedRows == 0 {
+		return database.DBResults{}, database.ErrDBVersionConflict
+	}
 
 	return res, nil
 }
 
-// Delete removes a employee from the database.
-func (s Store) Delete(ctx context.Context, id string, now time.Time) (database.DBResults, error) {
+// Delete removes a employee from the database. Like Update it only
+// applies to the record at the given version.
+func (s Store) Delete(ctx context.Context, id string, version int, now time.Time) (database.DBResults, error) {
 	data := struct {
 		ID        string    `db:"id"`
+		Version   int       `db:"version"`
 		DeletedOn time.Time `db:"deleted_on"`
 	}{
 		ID:        id,
+		Version:   version,
 		DeletedOn: now,
 	}
 
@@ -116,66 +118,245 @@ func (s Store) Delete(ctx context.Context, id string, now time.Time) (database.D
 	UPDATE
 		employee
 	SET
-		deleted_on = :deleted_on
+		deleted_on = :deleted_on,
+		version = version + 1
 	WHERE
-		id = :id`
+		id = :id
+		and version = :version`
 
 	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
 	if err != nil {
 		return database.DBResults{}, fmt.Errorf("deleting employee id[%s]: %w", id, err)
 	}
+	if res.AffectedRows == 0 {
+		return database.DBResults{}, database.ErrDBVersionConflict
+	}
 
 	return res, nil
 }
 
 // Query retrieves a list of existing employee from the database.
-func (s Store) Query(ctx context.Context, pagi database.Pagination) ([]Employee, error) {
+//
+// One row more than the page size is read so the caller can tell whether
+// there is a next page, see database.PageWindow.
+func (s Store) Query(ctx context.Context, filter QueryFilter, pagi database.Pagination) ([]Employee, error) {
+	data := map[string]interface{}{
+		"page":     pagi.Page,
+		"per_page": pagi.PerPage + 1,
+	}
+
+	where := filterConditions(filter, "p.title", data)
+	if cond := database.KeysetCondition(pagi, "e.", data); cond != "" {
+		where = append(where, cond)
+	}
+
 	q := database.PaginationQuery(pagi, `
 	SELECT
-		id,
-	    name,
-	    position,
-	    created_on,
-	    updated_on,
-	    deleted_on
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
 	FROM
-		employee
-	WHERE
-		deleted_on is null
+		employee e
+		LEFT JOIN position p ON p.id = e.position_id
+	`+whereClause(where)+`
 	ORDER BY
-		:sort :direction,
-		id :direction
+		e.:sort :direction,
+		e.id :direction
 	LIMIT
 		:page,:per_page`)
 
 	// Slice to hold results
 	var res []Employee
-	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, pagi, &res); err != nil {
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
 		return nil, fmt.Errorf("selecting employee: %w", err)
 	}
 
+	// Rows read walking back are put back in the listing order.
+	if pagi.Cursor != nil && pagi.Cursor.Before {
+		slices.Reverse(res)
+	}
+
 	return res, nil
 }
 
+// filterConditions turns the filter into WHERE conditions. Every value is
+// bound as a named parameter in data, only the fixed clauses below and the
+// column holding the position title are joined into the query.
+func filterConditions(filter QueryFilter, title string, data map[string]interface{}) []string {
+	var where []string
+	if !filter.IncludeDeleted {
+		where = append(where, "e.deleted_on is null")
+	}
+	if filter.DepartmentID != nil {
+		where = append(where, "e.department_id = :department_id")
+		data["department_id"] = *filter.DepartmentID
+	}
+	if filter.NamePrefix != nil {
+		where = append(where, "e.name like :name_prefix")
+		data["name_prefix"] = escapeLike(*filter.NamePrefix) + "%"
+	}
+	if filter.NameContains != nil {
+		where = append(where, "e.name like :name_contains")
+		data["name_contains"] = "%" + escapeLike(*filter.NameContains) + "%"
+	}
+	if filter.Position != nil {
+		if _, err := strconv.ParseUint(*filter.Position, 10, 64); err == nil {
+			where = append(where, "e.position_id = :position")
+		} else {
+			where = append(where, "lower("+title+") = lower(:position)")
+		}
+		data["position"] = *filter.Position
+	}
+	if filter.CreatedAfter != nil {
+		where = append(where, "e.created_on >= :created_after")
+		data["created_after"] = *filter.CreatedAfter
+	}
+	if filter.CreatedBefore != nil {
+		where = append(where, "e.created_on < :created_before")
+		data["created_before"] = *filter.CreatedBefore
+	}
+	if filter.UpdatedAfter != nil {
+		where = append(where, "e.updated_on >= :updated_after")
+		data["updated_after"] = *filter.UpdatedAfter
+	}
+
+	return where
+}
+
+// Count returns the number of records matching the filter.
+func (s Store) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	data := make(map[string]interface{})
+	where := filterConditions(filter, "p.title", data)
+
+	q := `
+	SELECT
+		count(*) AS total
+	FROM
+		employee e
+		LEFT JOIN position p ON p.id = e.position_id
+	` + whereClause(where)
+
+	var res struct {
+		Total int `db:"total"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return 0, fmt.Errorf("counting employee: %w", err)
+	}
+
+	return res.Total, nil
+}
+
+// Export calls fn with every employee matching the filter, in id order.
+// Rows are read from the cursor as fn consumes them.
+func (s Store) Export(ctx context.Context, filter QueryFilter, fn func(Employee) error) error {
+	data := make(map[string]interface{})
+	where := filterConditions(filter, "p.title", data)
+
+	q := `
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee e
+		LEFT JOIN position p ON p.id = e.position_id
+	` + whereClause(where) + `
+	ORDER BY
+		e.id`
+
+	var row Employee
+	if err := database.NamedQueryEach(ctx, s.log, s.db, q, data, &row, func() error { return fn(row) }); err != nil {
+		return fmt.Errorf("exporting employee: %w", err)
+	}
+
+	return nil
+}
+
+// whereClause joins the conditions into a WHERE clause, empty when there
+// are none.
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE\n\t\t" + strings.Join(conds, "\n\t\tand ")
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so user input only
+// matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // QueryByID retrieves a list of existing requesting sources from the database.
 func (s Store) QueryByID(ctx context.Context, id string) (Employee, error) {
+	return s.queryByID(ctx, id, false, "")
+}
+
+// QueryByIDForUpdate retrieves the record like QueryByID and locks it until
+// the end of the transaction, so it cannot change between being read and
+// written.
+func (s Store) QueryByIDForUpdate(ctx context.Context, id string) (Employee, error) {
+	return s.queryByID(ctx, id, false, "FOR UPDATE OF e")
+}
+
+// QueryByIDWithDeleted retrieves the record like QueryByID, deleted
+// records included.
+func (s Store) QueryByIDWithDeleted(ctx context.Context, id string) (Employee, error) {
+	return s.queryByID(ctx, id, true, "")
+}
+
+// QueryByIDWithDeletedForUpdate retrieves and locks the record like
+// QueryByIDForUpdate, deleted records included.
+func (s Store) QueryByIDWithDeletedForUpdate(ctx context.Context, id string) (Employee, error) {
+	return s.queryByID(ctx, id, true, "FOR UPDATE OF e")
+}
+
+// queryByID selects the record by id, lock is appended to the query.
+// Deleted records are only found when withDeleted is set.
+func (s Store) queryByID(ctx context.Context, id string, withDeleted bool, lock string) (Employee, error) {
 	data := struct {
 		ID string `db:"id"`
 	}{ID: id}
 
-	const q = `
+	q := `
 	SELECT
-		id,
-		name,
-		position,
-		created_on,
-		updated_on,
-		deleted_on
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
 	FROM
-		employee
+		employee e
+		LEFT JOIN position p ON p.id = e.position_id
 	WHERE
-		id = :id
-		and deleted_on is null`
+		e.id = :id
+	`
+	if !withDeleted {
+		q += "\tand e.deleted_on is null\n\t"
+	}
+	q += lock
 
 	// Slice to hold results
 	var res Employee
@@ -203,7 +384,8 @@ func (s Store) UnDelete(ctx context.Context, id string, now time.Time) (database
 		employee
 	SET
 		updated_on = :updated_on,
-		deleted_on = null
+		deleted_on = null,
+		version = version + 1
 	WHERE
 		id = :id`
 
@@ -214,3 +396,723 @@ func (s Store) UnDelete(ctx context.Context, id string, now time.Time) (database
 
 	return res, nil
 }
+
+// CreateAudit records a change of an employee.
+func (s Store) CreateAudit(ctx context.Context, a Audit) (database.DBResults, error) {
+	const q = `
+	INSERT INTO employee_audit
+		(employee_id, action, actor, trace_id, before_data, after_data, diff, created_on)
+	VALUES
+		(:employee_id, :action, :actor, :trace_id, :before_data, :after_data, :diff, :created_on)`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, a)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("inserting audit of employee id[%s]: %w", a.EmployeeID, err)
+	}
+
+	return res, nil
+}
+
+// QueryAudit retrieves a page of the changes of an employee ordered by
+// time. The snapshots missing on creation and deletion are read as JSON
+// null so the remaining columns are still scanned.
+func (s Store) QueryAudit(ctx context.Context, employeeID string, pagi database.Pagination) ([]Audit, error) {
+	data := map[string]interface{}{
+		"employee_id": employeeID,
+		"page":        pagi.Page,
+		"per_page":    pagi.PerPage,
+	}
+
+	q := database.PaginationQuery(pagi, `
+	SELECT
+		id,
+		employee_id,
+		action,
+		actor,
+		trace_id,
+		coalesce(before_data, cast('null' AS json)) AS before_data,
+		coalesce(after_data, cast('null' AS json)) AS after_data,
+		diff,
+		created_on
+	FROM
+		employee_audit
+	WHERE
+		employee_id = :employee_id
+	ORDER BY
+		created_on :direction,
+		id :direction
+	LIMIT
+		:page,:per_page`)
+
+	var res []Audit
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("selecting audit of employee id[%s]: %w", employeeID, err)
+	}
+
+	return res, nil
+}
+
+// CountAudit returns the number of changes recorded for an employee.
+func (s Store) CountAudit(ctx context.Context, employeeID string) (int, error) {
+	data := struct {
+		EmployeeID string `db:"employee_id"`
+	}{EmployeeID: employeeID}
+
+	const q = `
+	SELECT
+		count(*) AS total
+	FROM
+		employee_audit
+	WHERE
+		employee_id = :employee_id`
+
+	var res struct {
+		Total int `db:"total"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return 0, fmt.Errorf("counting audit of employee id[%s]: %w", employeeID, err)
+	}
+
+	return res.Total, nil
+}
+
+// employeesAsOf rebuilds the employees as they were at :as_of from the
+// state recorded last at or before it, else the state the first later
+// change started from, else the current record when nothing was recorded
+// since. Only the latest and next audit row of each employee is read. An
+// employee whose first later change is its creation did not exist yet and
+// is left out. Audit states hold times as RFC 3339 UTC strings, the query
+// text stays free of colons, which would be read as named parameters.
+const employeesAsOf = `
+	WITH
+		last_change AS (
+			SELECT employee_id, max(id) AS id FROM employee_audit
+			WHERE created_on <= :as_of
+			GROUP BY employee_id
+		),
+		next_change AS (
+			SELECT employee_id, min(id) AS id FROM employee_audit
+			WHERE created_on > :as_of
+			GROUP BY employee_id
+		),
+		state AS (
+			SELECT
+				e.id,
+				CASE
+					WHEN lc.id IS NOT NULL THEN la.after_data
+					WHEN nc.id IS NOT NULL THEN na.before_data
+					ELSE json_object(
+						'public_id', e.public_id,
+						'name', e.name,
+						'position', coalesce(p.title, ''),
+						'position_id', cast(e.position_id AS char),
+						'department_id', cast(e.department_id AS char),
+						'manager_id', cast(e.manager_id AS char),
+						'version', e.version,
+						'created_on', concat(replace(cast(e.created_on AS char), ' ', 'T'), 'Z'),
+						'updated_on', concat(replace(cast(e.updated_on AS char), ' ', 'T'), 'Z'),
+						'deleted_on', if(e.deleted_on <= :as_of, concat(replace(cast(e.deleted_on AS char), ' ', 'T'), 'Z'), null))
+				END AS data
+			FROM
+				employee e
+				LEFT JOIN position p ON p.id = e.position_id
+				LEFT JOIN last_change lc ON lc.employee_id = e.id
+				LEFT JOIN employee_audit la ON la.id = lc.id
+				LEFT JOIN next_change nc ON nc.employee_id = e.id
+				LEFT JOIN employee_audit na ON na.id = nc.id
+			WHERE
+				e.created_on <= :as_of
+		),
+		employee_as_of AS (
+			SELECT
+				s.data->>'$.public_id' AS public_id,
+				s.id,
+				s.data->>'$.name' AS name,
+				coalesce(s.data->>'$.position', '') AS position,
+				nullif(s.data->>'$.position_id', 'null') AS position_id,
+				nullif(s.data->>'$.department_id', 'null') AS department_id,
+				nullif(s.data->>'$.manager_id', 'null') AS manager_id,
+				cast(s.data->>'$.version' AS unsigned) AS version,
+				cast(replace(replace(s.data->>'$.created_on', 'T', ' '), 'Z', '') AS datetime(6)) AS created_on,
+				cast(replace(replace(s.data->>'$.updated_on', 'T', ' '), 'Z', '') AS datetime(6)) AS updated_on,
+				cast(replace(replace(nullif(s.data->>'$.deleted_on', 'null'), 'T', ' '), 'Z', '') AS datetime(6)) AS deleted_on
+			FROM
+				state s
+			WHERE
+				json_type(s.data) <> 'NULL'
+		)`
+
+// QueryAsOf retrieves a page of the employees matching the filter as they
+// were at asOf, see employeesAsOf. Pages are addressed by number.
+func (s Store) QueryAsOf(ctx context.Context, filter QueryFilter, pagi database.Pagination, asOf time.Time) ([]Employee, error) {
+	data := map[string]interface{}{
+		"as_of":    asOf,
+		"page":     pagi.Page,
+		"per_page": pagi.PerPage,
+	}
+
+	where := filterConditions(filter, "e.position", data)
+
+	q := database.PaginationQuery(pagi, employeesAsOf+`
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		e.position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee_as_of e
+	`+whereClause(where)+`
+	ORDER BY
+		e.:sort :direction,
+		e.id :direction
+	LIMIT
+		:page,:per_page`)
+
+	var res []Employee
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("selecting employees as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	return res, nil
+}
+
+// CountAsOf returns the number of employees matching the filter at asOf.
+func (s Store) CountAsOf(ctx context.Context, filter QueryFilter, asOf time.Time) (int, error) {
+	data := map[string]interface{}{
+		"as_of": asOf,
+	}
+
+	where := filterConditions(filter, "e.position", data)
+
+	q := employeesAsOf + `
+	SELECT
+		count(*) AS total
+	FROM
+		employee_as_of e
+	` + whereClause(where)
+
+	var res struct {
+		Total int `db:"total"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return 0, fmt.Errorf("counting employees as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+
+	return res.Total, nil
+}
+
+// QueryByIDAsOf retrieves the employee as it was at asOf, deleted or not.
+func (s Store) QueryByIDAsOf(ctx context.Context, id string, asOf time.Time) (Employee, error) {
+	data := map[string]interface{}{
+		"as_of": asOf,
+		"id":    id,
+	}
+
+	q := employeesAsOf + `
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		e.position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee_as_of e
+	WHERE
+		e.id = :id`
+
+	var res Employee
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Employee{}, fmt.Errorf("selecting by id[%q] as of %s: %w", id, asOf.Format(time.RFC3339), err)
+	}
+
+	return res, nil
+}
+
+// CreatePendingChange records a change to apply on its effective date.
+func (s Store) CreatePendingChange(ctx context.Context, pc PendingChange) (database.DBResults, error) {
+	const q = `
+	INSERT INTO employee_pending_change
+		(public_id, employee_id, changes, effective_on, status, actor, created_on, updated_on)
+	VALUES
+		(:public_id, :employee_id, :changes, :effective_on, :status, :actor, :created_on, :updated_on)`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, pc)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("inserting pending change of employee id[%s]: %w", pc.EmployeeID, err)
+	}
+
+	return res, nil
+}
+
+// QueryPendingChanges retrieves the changes of an employee in the status,
+// in the order they take effect.
+func (s Store) QueryPendingChanges(ctx context.Context, employeeID string, status string) ([]PendingChange, error) {
+	data := struct {
+		EmployeeID string `db:"employee_id"`
+		Status     string `db:"status"`
+	}{
+		EmployeeID: employeeID,
+		Status:     status,
+	}
+
+	const q = `
+	SELECT
+		id,
+		public_id,
+		employee_id,
+		changes,
+		effective_on,
+		status,
+		error,
+		attempts,
+		actor,
+		created_on,
+		updated_on
+	FROM
+		employee_pending_change
+	WHERE
+		employee_id = :employee_id
+		and status = :status
+	ORDER BY
+		effective_on,
+		id`
+
+	var res []PendingChange
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("selecting pending changes of employee id[%s]: %w", employeeID, err)
+	}
+
+	return res, nil
+}
+
+// QueryDuePendingChange retrieves and locks the earliest change in the
+// status whose effective date has come, past the after change in the same
+// order. Changes locked by another transaction are skipped so several
+// instances can apply changes at once.
+func (s Store) QueryDuePendingChange(ctx context.Context, status string, now time.Time, after PendingChange) (PendingChange, error) {
+	data := struct {
+		Status           string    `db:"status"`
+		Now              time.Time `db:"now"`
+		AfterEffectiveOn time.Time `db:"after_effective_on"`
+		AfterID          string    `db:"after_id"`
+	}{
+		Status:           status,
+		Now:              now,
+		AfterEffectiveOn: after.EffectiveOn,
+		AfterID:          after.ID,
+	}
+	if data.AfterID == "" {
+		data.AfterID = "0"
+	}
+
+	const q = `
+	SELECT
+		id,
+		public_id,
+		employee_id,
+		changes,
+		effective_on,
+		status,
+		error,
+		attempts,
+		actor,
+		created_on,
+		updated_on
+	FROM
+		employee_pending_change
+	WHERE
+		status = :status
+		and effective_on <= :now
+		and (effective_on > :after_effective_on or (effective_on = :after_effective_on and id > :after_id))
+	ORDER BY
+		effective_on,
+		id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED`
+
+	var res PendingChange
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return PendingChange{}, fmt.Errorf("selecting due pending change: %w", err)
+	}
+
+	return res, nil
+}
+
+// UpdatePendingChangeStatus moves a change of the employee, by public id,
+// from one status to another. database.ErrDBNotFound is returned if the
+// employee has no such change in the from status.
+func (s Store) UpdatePendingChangeStatus(ctx context.Context, employeeID string, publicID string, from string, to string, msg string, now time.Time) error {
+	data := struct {
+		PublicID   string    `db:"public_id"`
+		EmployeeID string    `db:"employee_id"`
+		From       string    `db:"from_status"`
+		To         string    `db:"to_status"`
+		Error      string    `db:"error"`
+		UpdatedOn  time.Time `db:"updated_on"`
+	}{
+		PublicID:   publicID,
+		EmployeeID: employeeID,
+		From:       from,
+		To:         to,
+		Error:      msg,
+		UpdatedOn:  now,
+	}
+
+	const q = `
+	UPDATE
+		employee_pending_change
+	SET
+		status = :to_status,
+		error = :error,
+		updated_on = :updated_on
+	WHERE
+		public_id = :public_id
+		and employee_id = :employee_id
+		and status = :from_status`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return fmt.Errorf("updating pending change public id[%s]: %w", publicID, err)
+	}
+	if res.AffectedRows == 0 {
+		return database.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// RecordPendingChangeAttempt counts a failed attempt at applying a change
+// in the from status together with its error. The change is moved to the
+// failed status once maxAttempts is reached.
+func (s Store) RecordPendingChangeAttempt(ctx context.Context, id string, from string, failed string, msg string, maxAttempts int, now time.Time) error {
+	data := struct {
+		ID          string    `db:"id"`
+		From        string    `db:"from_status"`
+		Failed      string    `db:"failed_status"`
+		Error       string    `db:"error"`
+		MaxAttempts int       `db:"max_attempts"`
+		UpdatedOn   time.Time `db:"updated_on"`
+	}{
+		ID:          id,
+		From:        from,
+		Failed:      failed,
+		Error:       msg,
+		MaxAttempts: maxAttempts,
+		UpdatedOn:   now,
+	}
+
+	// Assignments are applied in order, the status sees the new count.
+	const q = `
+	UPDATE
+		employee_pending_change
+	SET
+		attempts = attempts + 1,
+		status = if(attempts >= :max_attempts, :failed_status, status),
+		error = :error,
+		updated_on = :updated_on
+	WHERE
+		id = :id
+		and status = :from_status`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return fmt.Errorf("recording attempt of pending change id[%s]: %w", id, err)
+	}
+	if res.AffectedRows == 0 {
+		return database.ErrDBNotFound
+	}
+
+	return nil
+}
+
+// DepartmentExists reports whether an active department with the id exists.
+func (s Store) DepartmentExists(ctx context.Context, id string) (bool, error) {
+	data := struct {
+		ID string `db:"id"`
+	}{ID: id}
+
+	const q = `
+	SELECT
+		count(*) as total
+	FROM
+		department
+	WHERE
+		id = :id
+		and deleted_on is null`
+
+	var res struct {
+		Total int `db:"total"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return false, fmt.Errorf("selecting department id[%q]: %w", id, err)
+	}
+
+	return res.Total > 0, nil
+}
+
+// QueryReports retrieves the employees reporting to the manager, directly
+// and down to depth levels below, ordered by level.
+func (s Store) QueryReports(ctx context.Context, id string, depth int) ([]Employee, error) {
+	data := struct {
+		ID    string `db:"id"`
+		Depth int    `db:"depth"`
+	}{
+		ID:    id,
+		Depth: depth,
+	}
+
+	const q = `
+	WITH RECURSIVE reports (id, depth) AS (
+		SELECT
+			id,
+			1
+		FROM
+			employee
+		WHERE
+			manager_id = :id
+			and deleted_on is null
+		UNION ALL
+		SELECT
+			e.id,
+			r.depth + 1
+		FROM
+			employee e
+			JOIN reports r ON e.manager_id = r.id
+		WHERE
+			e.deleted_on is null
+			and r.depth < :depth
+	)
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee e
+		JOIN reports r ON e.id = r.id
+		LEFT JOIN position p ON p.id = e.position_id
+	ORDER BY
+		r.depth,
+		e.id`
+
+	var res []Employee
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("selecting reports of id[%q]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// QueryChain retrieves the management chain of the employee, from the
+// direct manager up to the root. The walk stops after maxDepth levels so
+// inconsistent data can never loop.
+func (s Store) QueryChain(ctx context.Context, id string, maxDepth int) ([]Employee, error) {
+	data := struct {
+		ID       string `db:"id"`
+		MaxDepth int    `db:"max_depth"`
+	}{
+		ID:       id,
+		MaxDepth: maxDepth,
+	}
+
+	const q = `
+	WITH RECURSIVE chain (id, manager_id, depth) AS (
+		SELECT
+			id,
+			manager_id,
+			0
+		FROM
+			employee
+		WHERE
+			id = :id
+		UNION ALL
+		SELECT
+			e.id,
+			e.manager_id,
+			c.depth + 1
+		FROM
+			employee e
+			JOIN chain c ON e.id = c.manager_id
+		WHERE
+			e.deleted_on is null
+			and c.depth < :max_depth
+	)
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee e
+		JOIN chain c ON e.id = c.id
+		LEFT JOIN position p ON p.id = e.position_id
+	WHERE
+		c.depth > 0
+	ORDER BY
+		c.depth`
+
+	var res []Employee
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("selecting chain of id[%q]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// QueryAll retrieves every employee not soft deleted, used to build the
+// org chart.
+func (s Store) QueryAll(ctx context.Context) ([]Employee, error) {
+	const q = `
+	SELECT
+		e.public_id,
+		e.id,
+		e.name,
+		coalesce(p.title, '') AS position,
+		e.position_id,
+		e.department_id,
+		e.manager_id,
+		e.version,
+		e.created_on,
+		e.updated_on,
+		e.deleted_on
+	FROM
+		employee e
+		LEFT JOIN position p ON p.id = e.position_id
+	WHERE
+		e.deleted_on is null
+	ORDER BY
+		e.id`
+
+	var res []Employee
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &res); err != nil {
+		return nil, fmt.Errorf("selecting employees: %w", err)
+	}
+
+	return res, nil
+}
+
+// QueryPositionByID retrieves the active catalog position with the id.
+func (s Store) QueryPositionByID(ctx context.Context, id string) (Position, error) {
+	data := struct {
+		ID string `db:"id"`
+	}{ID: id}
+
+	const q = `
+	SELECT
+		id,
+		title
+	FROM
+		position
+	WHERE
+		id = :id
+		and deleted_on is null`
+
+	var res Position
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Position{}, fmt.Errorf("selecting position id[%q]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// QueryPositionByTitle retrieves the active catalog position with the
+// title, compared without regard to case.
+func (s Store) QueryPositionByTitle(ctx context.Context, title string) (Position, error) {
+	data := struct {
+		Title string `db:"title"`
+	}{Title: title}
+
+	const q = `
+	SELECT
+		id,
+		title
+	FROM
+		position
+	WHERE
+		lower(title) = lower(:title)
+		and deleted_on is null`
+
+	var res Position
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Position{}, fmt.Errorf("selecting position title[%q]: %w", title, err)
+	}
+
+	return res, nil
+}
+
+// CreatePosition adds the title to the position catalog, for employees
+// naming a position the catalog does not have yet.
+func (s Store) CreatePosition(ctx context.Context, pos Position, now time.Time) (database.DBResults, error) {
+	data := struct {
+		Title     string    `db:"title"`
+		CreatedOn time.Time `db:"created_on"`
+	}{
+		Title:     pos.Title,
+		CreatedOn: now,
+	}
+
+	const q = `
+	INSERT INTO position
+		(title, created_on, updated_on)
+	VALUES
+		(:title, :created_on, :created_on)`
+
+	res, err := database.NamedExecContext(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return database.DBResults{}, fmt.Errorf("inserting position title[%q]: %w", pos.Title, err)
+	}
+
+	return res, nil
+}
+
+// QueryIDByPublicID retrieves the id of the employee with the public id,
+// soft deleted employees included.
+func (s Store) QueryIDByPublicID(ctx context.Context, publicID string) (string, error) {
+	data := struct {
+		PublicID string `db:"public_id"`
+	}{PublicID: publicID}
+
+	const q = `
+	SELECT
+		id
+	FROM
+		employee
+	WHERE
+		public_id = :public_id`
+
+	var res struct {
+		ID string `db:"id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return "", fmt.Errorf("selecting by public id[%q]: %w", publicID, err)
+	}
+
+	return res.ID, nil
+}
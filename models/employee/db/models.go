@@ -1,16 +1,69 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // Employee represent the structure we need for moving data
 // between the app and the database.
 type Employee struct {
-	ID        string     `db:"id"`
-	Name      string     `db:"name"`
-	Position  string     `db:"position"`
-	CreatedOn time.Time  `db:"created_on"`
-	UpdatedOn time.Time  `db:"updated_on"`
-	DeletedOn *time.Time `db:"deleted_on"`
+	PublicID     string     `db:"public_id"`
+	ID           string     `db:"id"`
+	Name         string     `db:"name"`
+	Position     string     `db:"position"`
+	PositionID   *string    `db:"position_id"`
+	DepartmentID *string    `db:"department_id"`
+	ManagerID    *string    `db:"manager_id"`
+	Version      int        `db:"version"`
+	CreatedOn    time.Time  `db:"created_on"`
+	UpdatedOn    time.Time  `db:"updated_on"`
+	DeletedOn    *time.Time `db:"deleted_on"`
+}
+
+// Audit is a change of an employee recorded in employee_audit.
+type Audit struct {
+	ID         string          `db:"id"`
+	EmployeeID string          `db:"employee_id"`
+	Action     string          `db:"action"`
+	Actor      string          `db:"actor"`
+	TraceID    string          `db:"trace_id"`
+	Before     json.RawMessage `db:"before_data"`
+	After      json.RawMessage `db:"after_data"`
+	Diff       json.RawMessage `db:"diff"`
+	CreatedOn  time.Time       `db:"created_on"`
+}
+
+// PendingChange is a change of an employee waiting for the date it takes
+// effect on, recorded in employee_pending_change.
+type PendingChange struct {
+	ID          string          `db:"id"`
+	PublicID    string          `db:"public_id"`
+	EmployeeID  string          `db:"employee_id"`
+	Changes     json.RawMessage `db:"changes"`
+	EffectiveOn time.Time       `db:"effective_on"`
+	Status      string          `db:"status"`
+	Error       string          `db:"error"`
+	Attempts    int             `db:"attempts"`
+	Actor       string          `db:"actor"`
+	CreatedOn   time.Time       `db:"created_on"`
+	UpdatedOn   time.Time       `db:"updated_on"`
+}
+
+// Position is the catalog position referenced by an employee.
+type Position struct {
+	ID    string `db:"id"`
+	Title string `db:"title"`
+}
+
+// QueryFilter holds the optional filters applied when listing employees.
+type QueryFilter struct {
+	DepartmentID   *string
+	NamePrefix     *string
+	NameContains   *string
+	Position       *string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	IncludeDeleted bool
 }
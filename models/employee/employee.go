@@ -3,13 +3,14 @@ package employee
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/pansachin/employee-service/models/employee/db"
@@ -22,17 +23,30 @@ var (
 	ErrNotFound     = errors.New("employee not found")
 	ErrInvalidID    = errors.New("ID is not in its proper form")
 	ErrInvalidAlias = errors.New("alias is not in its proper form")
+
+	ErrInvalidDepartment = errors.New("department is not in its proper form")
+	ErrInvalidManager    = errors.New("manager is not in its proper form")
+	ErrInvalidPosition   = errors.New("position is not in its proper form")
+
+	ErrVersionMismatch = errors.New("employee has been modified")
 )
 
+// serializable is used by the transactions checking the references of an
+// employee. Their reads lock the rows read, so a department, position or
+// manager cannot be removed, nor a reporting cycle created, between the
+// check and the write. Conflicting transactions are aborted by MySQL and
+// retried by database.WithinTran.
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
 // Core manages the set of APIs for employee access
 type Core struct {
 	store db.Store
 }
 
 // NewCore constructs a core for employee api access.
-func NewCore(log *slog.Logger, sqlxDB *sqlx.DB, rwmux *sync.RWMutex) Core {
+func NewCore(log *slog.Logger, sqlxDB *sqlx.DB) Core {
 	return Core{
-		store: db.NewStore(log, sqlxDB, rwmux),
+		store: db.NewStore(log, sqlxDB),
 	}
 }
 
@@ -46,102 +60,269 @@ func (c Core) Create(ctx context.Context, rs NewEmployee, now time.Time) (Employ
 		return Employee{}, fmt.Errorf("validating data: %w", err)
 	}
 
+	// The references are checked in the transaction inserting the record,
+	// see serializable.
+	var emp Employee
+	tran := func(tx sqlx.ExtContext) error {
+		var err error
+		emp, err = c.create(ctx, c.store.Tran(tx), rs, now)
+		return err
+	}
+
+	if err := c.store.WithinTran(ctx, serializable, tran); err != nil {
+		return Employee{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return emp, nil
+}
+
+// create inserts the employee in the transaction of the store, together
+// with the record of the change. It is shared by Create and Batch.
+func (c Core) create(ctx context.Context, store db.Store, rs NewEmployee, now time.Time) (Employee, error) {
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return Employee{}, fmt.Errorf("generating public id: %w", err)
+	}
+
 	dbRS := db.Employee{
-		Name:      strings.TrimSpace(rs.Name),
-		Position:  strings.TrimSpace(rs.Position),
-		CreatedOn: now,
-		UpdatedOn: now,
+		PublicID:     publicID.String(),
+		Name:         strings.TrimSpace(rs.Name),
+		DepartmentID: rs.DepartmentID,
+		ManagerID:    rs.ManagerID,
+		Version:      1,
+		CreatedOn:    now,
+		UpdatedOn:    now,
 	}
 
-	// This provides an example of how to execute a transaction if required.
-	tran := func(tx sqlx.ExtContext) error {
-		res, err := c.store.Tran(tx).Create(ctx, dbRS)
-		if err != nil {
-			return err
-		}
-		dbRS.ID = fmt.Sprintf("%d", res.LastInsertID)
-		return nil
+	pos, err := c.resolvePosition(ctx, store, &rs.Position, rs.PositionID, now)
+	if err != nil {
+		return Employee{}, err
 	}
+	if err := c.checkDepartment(ctx, store, rs.DepartmentID); err != nil {
+		return Employee{}, err
+	}
+	if err := c.checkManager(ctx, store, "", rs.ManagerID); err != nil {
+		return Employee{}, err
+	}
+	dbRS.Position = pos.Title
+	dbRS.PositionID = positionID(pos)
 
-	if err := c.store.WithinTran(ctx, tran); err != nil {
-		return Employee{}, fmt.Errorf("tran: %w", err)
+	res, err := store.Create(ctx, dbRS)
+	if err != nil {
+		return Employee{}, err
+	}
+	dbRS.ID = fmt.Sprintf("%d", res.LastInsertID)
+
+	if err := c.audit(ctx, store, ChangeCreate, nil, dbRS, now); err != nil {
+		return Employee{}, err
 	}
 
 	return toEmployee(dbRS), nil
 }
 
-// Update replaces a employee document in the database.
-func (c Core) Update(ctx context.Context, id string, urs UpdateEmployee, now time.Time) error {
+// Update replaces a employee document in the database and returns the
+// employee updated. The record must meet a non nil match or
+// ErrVersionMismatch is returned.
+func (c Core) Update(ctx context.Context, id string, urs UpdateEmployee, match Match, now time.Time) (Employee, error) {
 	if err := validate.Check(urs); err != nil {
+		return Employee{}, err
+	}
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return Employee{}, err
+	}
+
+	// The checks and the update share a serializable transaction so
+	// concurrent updates cannot create a reporting cycle between them.
+	var dbRS db.Employee
+	tran := func(tx sqlx.ExtContext) error {
+		var err error
+		dbRS, err = c.update(ctx, c.store.Tran(tx), id, urs, match, now)
 		return err
 	}
-	if err := validate.CheckID(id); err != nil {
-		return ErrInvalidID
+
+	if err := c.store.WithinTran(ctx, serializable, tran); err != nil {
+		return Employee{}, fmt.Errorf("tran: %w", err)
 	}
 
-	dbRS, err := c.store.QueryByID(ctx, id)
+	return toEmployee(dbRS), nil
+}
+
+// update applies the changes to the employee in the transaction of the
+// store, together with the record of the change. It is shared by Update
+// and the pending changes applied on their effective date.
+func (c Core) update(ctx context.Context, store db.Store, id string, urs UpdateEmployee, match Match, now time.Time) (db.Employee, error) {
+	dbRS, err := store.QueryByIDForUpdate(ctx, id)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
-			return ErrNotFound
+			return db.Employee{}, ErrNotFound
 		}
-		return fmt.Errorf("updating employee id[%s]: %w", id, err)
+		return db.Employee{}, fmt.Errorf("updating employee id[%s]: %w", id, err)
 	}
+	if !match.met(dbRS) {
+		return db.Employee{}, ErrVersionMismatch
+	}
+	before := dbRS
 
 	isEmpty := true
-	if urs.Position != nil {
-		dbRS.Position = strings.TrimSpace(*urs.Position)
+	if urs.Position != nil || urs.PositionID != nil {
+		pos, err := c.resolvePosition(ctx, store, urs.Position, urs.PositionID, now)
+		if err != nil {
+			return db.Employee{}, err
+		}
+		dbRS.Position = pos.Title
+		dbRS.PositionID = positionID(pos)
+		isEmpty = false
+	}
+	if urs.DepartmentID != nil {
+		if err := c.checkDepartment(ctx, store, urs.DepartmentID); err != nil {
+			return db.Employee{}, err
+		}
+		dbRS.DepartmentID = urs.DepartmentID
+		isEmpty = false
+	}
+	if urs.ManagerID != nil {
+		dbRS.ManagerID = nil
+		if *urs.ManagerID != "" {
+			if err := c.checkManager(ctx, store, id, urs.ManagerID); err != nil {
+				return db.Employee{}, err
+			}
+			dbRS.ManagerID = urs.ManagerID
+		}
 		isEmpty = false
 	}
 	// No changes were made - don't touch the DB
 	if isEmpty {
-		return nil
+		return dbRS, nil
 	}
 	dbRS.UpdatedOn = now
 
-	_, err = c.store.Update(ctx, dbRS)
-	if err != nil {
-		return fmt.Errorf("update id[%s]: %w", id, err)
+	if _, err := store.Update(ctx, dbRS); err != nil {
+		if errors.Is(err, database.ErrDBVersionConflict) {
+			return db.Employee{}, ErrVersionMismatch
+		}
+		return db.Employee{}, fmt.Errorf("update id[%s]: %w", id, err)
 	}
+	dbRS.Version++
 
-	return nil
+	if err := c.audit(ctx, store, ChangeUpdate, &before, dbRS, now); err != nil {
+		return db.Employee{}, err
+	}
+
+	return dbRS, nil
 }
 
-// Delete removes a employee from the database.
-func (c Core) Delete(ctx context.Context, id string, now time.Time) error {
-	if err := validate.CheckID(id); err != nil {
-		return ErrInvalidID
+// Delete removes a employee from the database. The record must meet a non
+// nil match or ErrVersionMismatch is returned.
+func (c Core) Delete(ctx context.Context, id string, match Match, now time.Time) error {
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	_, err := c.store.QueryByID(ctx, id)
+	tran := func(tx sqlx.ExtContext) error {
+		return c.delete(ctx, c.store.Tran(tx), id, match, now)
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// delete removes the employee in the transaction of the store, together
+// with the record of the change. It is shared by Delete and Batch.
+func (c Core) delete(ctx context.Context, store db.Store, id string, match Match, now time.Time) error {
+	dbRS, err := store.QueryByIDForUpdate(ctx, id)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return ErrNotFound
 		}
-		return fmt.Errorf("undeleting employee id[%s]: %w", id, err)
+		return fmt.Errorf("deleting employee id[%s]: %w", id, err)
+	}
+	if !match.met(dbRS) {
+		return ErrVersionMismatch
 	}
 
-	_, err = c.store.Delete(ctx, id, now)
-	if err != nil {
+	if _, err := store.Delete(ctx, id, dbRS.Version, now); err != nil {
+		if errors.Is(err, database.ErrDBVersionConflict) {
+			return ErrVersionMismatch
+		}
 		return fmt.Errorf("delete id[%s]: %w", id, err)
 	}
 
-	return nil
+	after := dbRS
+	after.Version++
+	after.DeletedOn = &now
+	return c.audit(ctx, store, ChangeDelete, &dbRS, after, now)
 }
 
-// Query retrieves a list of existing records from the database
-func (c Core) Query(ctx context.Context, pagi database.Pagination) ([]Employee, error) {
-	res, err := c.store.Query(ctx, pagi)
+// Query retrieves a page of existing records from the database together
+// with the cursors to the pages around it.
+func (c Core) Query(ctx context.Context, filter QueryFilter, pagi database.Pagination) ([]Employee, database.Cursors, error) {
+	if filter.DepartmentID != nil {
+		if err := validate.CheckID(*filter.DepartmentID); err != nil {
+			return nil, database.Cursors{}, ErrInvalidDepartment
+		}
+	}
+
+	res, err := c.store.Query(ctx, toDBQueryFilter(filter), pagi)
 	if err != nil {
-		return nil, fmt.Errorf("query: %w", err)
+		return nil, database.Cursors{}, fmt.Errorf("query: %w", err)
+	}
+
+	start, end, more := database.PageWindow(pagi, len(res))
+	rs := toEmployeeSlice(res[start:end])
+	if len(rs) == 0 {
+		return rs, database.Cursors{}, nil
 	}
 
-	return toEmployeeSlice(res), nil
+	cursors := database.PageCursors(pagi, more, cursorAt(rs[0], pagi.Sort), cursorAt(rs[len(rs)-1], pagi.Sort))
+
+	return rs, cursors, nil
+}
+
+// Count returns the number of records matching the filter.
+func (c Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	if filter.DepartmentID != nil {
+		if err := validate.CheckID(*filter.DepartmentID); err != nil {
+			return 0, ErrInvalidDepartment
+		}
+	}
+
+	total, err := c.store.Count(ctx, toDBQueryFilter(filter))
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+
+	return total, nil
+}
+
+// Export calls fn with every employee matching the filter, in id order,
+// without holding the result in memory. It stops at the first error of fn.
+func (c Core) Export(ctx context.Context, filter QueryFilter, fn func(Employee) error) error {
+	if filter.DepartmentID != nil {
+		if err := validate.CheckID(*filter.DepartmentID); err != nil {
+			return ErrInvalidDepartment
+		}
+	}
+
+	err := c.store.Export(ctx, toDBQueryFilter(filter), func(dbRS db.Employee) error {
+		return fn(toEmployee(dbRS))
+	})
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	return nil
 }
 
 // QueryByID retrieves a single records from the database by id
 func (c Core) QueryByID(ctx context.Context, id string) (Employee, error) {
-	if err := validate.CheckID(id); err != nil {
-		return Employee{}, ErrInvalidID
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return Employee{}, err
 	}
 
 	res, err := c.store.QueryByID(ctx, id)
@@ -157,13 +338,167 @@ func (c Core) QueryByID(ctx context.Context, id string) (Employee, error) {
 
 // UnDelete restore a deleted employee from the database.
 func (c Core) UnDelete(ctx context.Context, id string, now time.Time) error {
+	id, err := c.resolveID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbRS, err := store.QueryByIDWithDeletedForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("undeleting employee id[%s]: %w", id, err)
+		}
+		// Nothing to restore, keep the history free of empty changes.
+		if dbRS.DeletedOn == nil {
+			return nil
+		}
+
+		if _, err := store.UnDelete(ctx, id, now); err != nil {
+			return fmt.Errorf("employee id[%s]: %w", id, err)
+		}
+
+		after := dbRS
+		after.Version++
+		after.UpdatedOn = now
+		after.DeletedOn = nil
+		return c.audit(ctx, store, ChangeUndelete, &dbRS, after, now)
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// resolveID accepts either identifier of an employee, the numeric id or the
+// public id, and returns the numeric id used by the store.
+func (c Core) resolveID(ctx context.Context, id string) (string, error) {
+	if err := validate.CheckUUID(id); err == nil {
+		numID, err := c.store.QueryIDByPublicID(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return "", ErrNotFound
+			}
+			return "", fmt.Errorf("resolving public id[%s]: %w", id, err)
+		}
+		return numID, nil
+	}
+
 	if err := validate.CheckID(id); err != nil {
-		return ErrInvalidID
+		return "", ErrInvalidID
+	}
+
+	return id, nil
+}
+
+// resolvePosition finds the catalog position referenced either by id or,
+// for backward compatibility, by title, like findPosition. A title not in
+// the catalog yet is added to it, as any title was accepted before there
+// was a catalog.
+func (c Core) resolvePosition(ctx context.Context, store db.Store, title *string, id *string, now time.Time) (db.Position, error) {
+	pos, err := c.findPosition(ctx, store, title, id)
+	if err != nil || pos.ID != "" || pos.Title == "" {
+		return pos, err
+	}
+
+	res, err := store.CreatePosition(ctx, pos, now)
+	if err == nil {
+		pos.ID = fmt.Sprintf("%d", res.LastInsertID)
+		return pos, nil
+	}
+	if !errors.Is(err, database.ErrDBDuplicatedEntry) {
+		return db.Position{}, fmt.Errorf("adding position title[%s]: %w", pos.Title, err)
 	}
 
-	_, err := c.store.UnDelete(ctx, id, now)
+	// Either another request added the title first, or the position was
+	// deleted from the catalog and the title is still taken.
+	pos, err = store.QueryPositionByTitle(ctx, pos.Title)
 	if err != nil {
-		return fmt.Errorf("employee id[%s]: %w", id, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return db.Position{}, validate.FieldErrors{
+				FieldError: []validate.FieldError{{Field: "position", Error: "position was removed from the catalog"}},
+			}
+		}
+		return db.Position{}, fmt.Errorf("checking position title[%s]: %w", *title, err)
+	}
+
+	return pos, nil
+}
+
+// findPosition finds the catalog position referenced either by id or, for
+// backward compatibility, by title. Blank values resolve to no position and
+// a title not in the catalog to a position without id. When both are
+// provided they must name the same position.
+func (c Core) findPosition(ctx context.Context, store db.Store, title *string, id *string) (db.Position, error) {
+	fieldErr := func(field string, msg string) error {
+		return validate.FieldErrors{
+			FieldError: []validate.FieldError{{Field: field, Error: msg}},
+		}
+	}
+
+	var byTitle db.Position
+	if title != nil && strings.TrimSpace(*title) != "" {
+		pos, err := store.QueryPositionByTitle(ctx, strings.TrimSpace(*title))
+		switch {
+		case errors.Is(err, database.ErrDBNotFound):
+			byTitle = db.Position{Title: strings.TrimSpace(*title)}
+		case err != nil:
+			return db.Position{}, fmt.Errorf("checking position title[%s]: %w", *title, err)
+		default:
+			byTitle = pos
+		}
+	}
+
+	if id == nil || strings.TrimSpace(*id) == "" {
+		return byTitle, nil
+	}
+
+	if err := validate.CheckID(*id); err != nil {
+		return db.Position{}, fieldErr("position_id", ErrInvalidPosition.Error())
+	}
+
+	pos, err := store.QueryPositionByID(ctx, *id)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return db.Position{}, fieldErr("position_id", "position does not exist")
+		}
+		return db.Position{}, fmt.Errorf("checking position id[%s]: %w", *id, err)
+	}
+	if byTitle.Title != "" && byTitle.ID != pos.ID {
+		return db.Position{}, fieldErr("position", "position does not match position_id")
+	}
+
+	return pos, nil
+}
+
+// checkDepartment validates that the department, when provided, exists.
+func (c Core) checkDepartment(ctx context.Context, store db.Store, id *string) error {
+	if id == nil {
+		return nil
+	}
+
+	fieldErr := func(msg string) error {
+		return validate.FieldErrors{
+			FieldError: []validate.FieldError{{Field: "department_id", Error: msg}},
+		}
+	}
+
+	if err := validate.CheckID(*id); err != nil {
+		return fieldErr(ErrInvalidDepartment.Error())
+	}
+
+	exists, err := store.DepartmentExists(ctx, *id)
+	if err != nil {
+		return fmt.Errorf("checking department id[%s]: %w", *id, err)
+	}
+	if !exists {
+		return fieldErr("department does not exist")
 	}
 
 	return nil
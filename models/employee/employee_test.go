@@ -2,10 +2,15 @@ package employee_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -24,13 +29,16 @@ type TestSuite struct {
 	log      *slog.Logger
 	teardown func()
 
-	tableName string
-	ctx       context.Context
-	rwmux     *sync.RWMutex
+	tableNames []string
+	ctx        context.Context
 }
 
 var ts TestSuite
-var TableName = "employee"
+
+// TableNames are copied into test_db, employees reference positions and
+// departments, their changes are recorded in employee_audit and the ones
+// taking effect later in employee_pending_change.
+var TableNames = []string{"position", "department", "employee", "employee_audit", "employee_pending_change"}
 
 func TestMain(m *testing.M) {
 	success := m.Run()
@@ -39,27 +47,29 @@ func TestMain(m *testing.M) {
 }
 
 func setupTestDB() error {
-	// Make sure we have sufficient permission for the db user
-	q := fmt.Sprintf("create table if not exists test_db.%s like %s.%s", ts.tableName, dbtest.UnitDbConfig.Name, ts.tableName)
-	if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
-		return fmt.Errorf("creating test_db.%s test table: %v", ts.tableName, err)
-	}
+	for _, tableName := range ts.tableNames {
+		// Make sure we have sufficient permission for the db user
+		q := fmt.Sprintf("create table if not exists test_db.%s like %s.%s", tableName, dbtest.UnitDbConfig.Name, tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("creating test_db.%s test table: %v", tableName, err)
+		}
 
-	q = fmt.Sprintf("truncate table test_db.%s", ts.tableName)
-	if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
-		return fmt.Errorf("truncating test_db.%s test table: %v", ts.tableName, err)
-	}
+		q = fmt.Sprintf("truncate table test_db.%s", tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("truncating test_db.%s test table: %v", tableName, err)
+		}
 
-	q = fmt.Sprintf(`
-		insert into test_db.%s
-		select * from %s.%s
-		order by created_on desc limit 100`,
-		ts.tableName,
-		dbtest.UnitDbConfig.Name,
-		ts.tableName,
-	)
-	if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
-		return fmt.Errorf("error copying data to test_db.%s test table: %v", ts.tableName, err)
+		q = fmt.Sprintf(`
+			insert into test_db.%s
+			select * from %s.%s
+			order by created_on desc limit 100`,
+			tableName,
+			dbtest.UnitDbConfig.Name,
+			tableName,
+		)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("error copying data to test_db.%s test table: %v", tableName, err)
+		}
 	}
 
 	return nil
@@ -69,19 +79,17 @@ func registerTestSuite(t *testing.T) {
 	if ts.db == nil {
 		log, db, teardown := dbtest.NewUnit(t)
 		ctx := context.Background()
-		rwmux := &sync.RWMutex{}
 		ts = TestSuite{
-			db:        db,
-			log:       log,
-			teardown:  teardown,
-			ctx:       ctx,
-			tableName: TableName,
-			rwmux:     rwmux,
+			db:         db,
+			log:        log,
+			teardown:   teardown,
+			ctx:        ctx,
+			tableNames: TableNames,
 		}
 
-		t.Logf("Create test database test_db.%s", ts.tableName)
+		t.Logf("Create test database tables %v", ts.tableNames)
 		if err := setupTestDB(); err != nil {
-			t.Fatalf("Failed to create test_db.%s: %v", ts.tableName, err)
+			t.Fatalf("Failed to create test tables: %v", err)
 		}
 	}
 }
@@ -90,7 +98,7 @@ func Test_EmployeeCRUD(t *testing.T) {
 	registerTestSuite(t)
 
 	// Use throughout the test
-	rsc := employee.NewCore(ts.log, ts.db, ts.rwmux)
+	rsc := employee.NewCore(ts.log, ts.db)
 
 	t.Log("Given the need to work with Employees")
 	{
@@ -126,11 +134,33 @@ func Test_EmployeeCRUD(t *testing.T) {
 			t.Logf("\t%s\tTest %d:\tShould get back the same Employee", dbtest.Success, testID)
 			testID++
 
+			// QUERY BY PUBLIC ID
+			byPublicID, err := rsc.QueryByID(ts.ctx, newRecord.PublicID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve Employee by public ID: %s.", dbtest.Failed, testID, err)
+			}
+			if diff := cmp.Diff(newRecord, byPublicID); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same Employee by public ID. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve Employee by public ID.", dbtest.Success, testID)
+			testID++
+
+			// QUERY BY SEQUENTIAL ID
+			if validate.CheckID(newRecord.ID) != nil || validate.CheckUUID(newRecord.PublicID) != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould identify the Employee by a sequential and a public ID: %q %q.", dbtest.Failed, testID, newRecord.ID, newRecord.PublicID)
+			}
+			_, err = rsc.QueryByID(ts.ctx, "923498273")
+			if !errors.Is(err, employee.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould look a sequential ID up instead of refusing it: %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould accept sequential IDs next to public IDs.", dbtest.Success, testID)
+			testID++
+
 			// UPDATE - NON-EXISTING RECORD
 			us := employee.UpdateEmployee{
-				Position: dbtest.StringPointer("Seniro Software Engineer"),
+				Position: dbtest.StringPointer("Staff Engineer"),
 			}
-			err = rsc.Update(ts.ctx, "923498273", us, now)
+			_, err = rsc.Update(ts.ctx, "923498273", us, nil, now)
 			if !errors.Is(err, employee.ErrNotFound) {
 				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update non-existing source : %s", dbtest.Failed, testID, err)
 			}
@@ -138,14 +168,45 @@ func Test_EmployeeCRUD(t *testing.T) {
 			testID++
 
 			// UPDATE
-			if err := rsc.Update(ts.ctx, newRecord.ID, us, now); err != nil {
+			updated, err := rsc.Update(ts.ctx, newRecord.ID, us, employee.MatchVersion(newRecord.Version), now)
+			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to update the Employee: %s", dbtest.Failed, testID, err)
 			}
+			if updated.Position != "Staff Engineer" || updated.ETag() == newRecord.ETag() {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the updated Employee with a new ETag : %+v", dbtest.Failed, testID, updated)
+			}
 			t.Logf("\t%s\tTest %d:\tShould be able to update the Employee", dbtest.Success, testID)
 			testID++
 
+			// UPDATE - STALE VERSION
+			_, err = rsc.Update(ts.ctx, newRecord.ID, us, employee.MatchVersion(newRecord.Version), now)
+			if !errors.Is(err, employee.ErrVersionMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update a stale version : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to update a stale version", dbtest.Success, testID)
+			testID++
+
+			err = rsc.Delete(ts.ctx, newRecord.ID, employee.MatchVersion(newRecord.Version), now)
+			if !errors.Is(err, employee.ErrVersionMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete a stale version : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete a stale version", dbtest.Success, testID)
+			testID++
+
+			// UPDATE - IF-MATCH
+			us = employee.UpdateEmployee{Position: dbtest.StringPointer("Principal Engineer")}
+			_, err = rsc.Update(ts.ctx, newRecord.ID, us, employee.MatchETag(newRecord.ETag()), now)
+			if !errors.Is(err, employee.ErrVersionMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update with a stale ETag : %v", dbtest.Failed, testID, err)
+			}
+			if _, err := rsc.Update(ts.ctx, newRecord.ID, us, employee.MatchETag(newRecord.ETag(), updated.ETag()), now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update when any ETag matches : %s", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only update when one of the ETags matches", dbtest.Success, testID)
+			testID++
+
 			// SOFT DELETE
-			if err := rsc.Delete(ts.ctx, newRecord.ID, time.Now()); err != nil {
+			if err := rsc.Delete(ts.ctx, newRecord.ID, nil, time.Now()); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to soft delete Employee : %s", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to soft delete Employee", dbtest.Success, testID)
@@ -167,7 +228,7 @@ func Test_EmployeePagination(t *testing.T) {
 	registerTestSuite(t)
 
 	// Use throughout the test
-	rsc := employee.NewCore(ts.log, ts.db, ts.rwmux)
+	rsc := employee.NewCore(ts.log, ts.db)
 
 	// Generate some data
 	var rt employee.NewEmployee
@@ -185,7 +246,7 @@ func Test_EmployeePagination(t *testing.T) {
 			pagi.PerPage = 1
 
 			// GET FIRST RECORD
-			s1, err := rsc.Query(ts.ctx, pagi)
+			s1, _, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve Employee for page 1 : %s", dbtest.Failed, testID, err)
 			}
@@ -200,7 +261,7 @@ func Test_EmployeePagination(t *testing.T) {
 
 			// GET SECOND RECORD
 			pagi.Page = 1
-			s2, err := rsc.Query(ts.ctx, pagi)
+			s2, _, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve Employee for page 2 : %s", dbtest.Failed, testID, err)
 			}
@@ -225,7 +286,7 @@ func Test_EmployeePagination(t *testing.T) {
 			// GET 3 RECORDS AND MAKE SURE THE ABOVE 2 MATCH
 			pagi.Page = 0
 			pagi.PerPage = 3
-			three, err := rsc.Query(ts.ctx, pagi)
+			three, _, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve Employee for 3 records : %s", dbtest.Failed, testID, err)
 			}
@@ -245,6 +306,39 @@ func Test_EmployeePagination(t *testing.T) {
 				t.Fatalf("\t%s\tTest %d:\tShould have different Employees", dbtest.Failed, testID)
 			}
 			t.Logf("\t%s\tTest %d:\tShould have different Employees", dbtest.Success, testID)
+			testID++
+
+			// WALK FORWARD AND BACK WITH CURSORS
+			pagi.PerPage = 1
+			_, cursors, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
+			if err != nil || cursors.Next == "" || cursors.Prev != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get only a next cursor on the first page : %v %v", dbtest.Failed, testID, cursors, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get only a next cursor on the first page", dbtest.Success, testID)
+			testID++
+
+			c, err := database.DecodeCursor(cursors.Next)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould decode the next cursor : %s", dbtest.Failed, testID, err)
+			}
+			pagi.Cursor = &c
+			next, cursors, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
+			if err != nil || len(next) != 1 || next[0].ID != three[1].ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the second Employee from the next cursor : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the second Employee from the next cursor", dbtest.Success, testID)
+			testID++
+
+			c, err = database.DecodeCursor(cursors.Prev)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould decode the previous cursor : %s", dbtest.Failed, testID, err)
+			}
+			pagi.Cursor = &c
+			prev, _, err := rsc.Query(ts.ctx, employee.QueryFilter{}, pagi)
+			if err != nil || len(prev) != 1 || prev[0].ID != three[0].ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the first Employee from the previous cursor : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the first Employee from the previous cursor", dbtest.Success, testID)
 		}
 	}
 }
@@ -252,7 +346,7 @@ func Test_EmployeeCRUDValidation(t *testing.T) {
 	registerTestSuite(t)
 
 	// Use throughout the test
-	rtc := employee.NewCore(ts.log, ts.db, ts.rwmux)
+	rtc := employee.NewCore(ts.log, ts.db)
 
 	t.Log("Given the need to get specific CRUD model validation error messages")
 	{
@@ -280,7 +374,615 @@ func Test_EmployeeCRUDValidation(t *testing.T) {
 			} else {
 				t.Logf("\t%s\tTest %d [Create]:\tValidation error count.", dbtest.Success, testID)
 			}
+			testID++
+
+			// CREATE with a position missing from the catalog
+			title := fmt.Sprintf("Unlisted Engineer %d", time.Now().UnixNano())
+			added, err := rtc.Create(ts.ctx, employee.NewEmployee{Name: "Sachin Prasad", Position: " " + title + " "}, now)
+			if err != nil || added.PositionID == nil || added.Position != title {
+				t.Fatalf("\t%s\tTest %d [Create]:\tExpecting the position to be added to the catalog : %+v %v", dbtest.Failed, testID, added, err)
+			}
+			again, err := rtc.Create(ts.ctx, employee.NewEmployee{Name: "Sachin Prasad", Position: strings.ToLower(title)}, now)
+			if err != nil || again.PositionID == nil || *again.PositionID != *added.PositionID {
+				t.Fatalf("\t%s\tTest %d [Create]:\tExpecting the added position to be reused : %+v %v", dbtest.Failed, testID, again, err)
+			}
+			t.Logf("\t%s\tTest %d [Create]:\tExpecting the position to be added to the catalog once", dbtest.Success, testID)
+			testID++
+
+			// CREATE with a position longer than the catalog title
+			_, err = rtc.Create(ts.ctx, employee.NewEmployee{Name: "Sachin Prasad", Position: strings.Repeat("x", 57)}, now)
+			if _, ok := validate.GetFieldErrors(err).Fields()["position"]; !ok {
+				t.Fatalf("\t%s\tTest %d [Create]:\tExpecting a position field error : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d [Create]:\tExpecting a position field error", dbtest.Success, testID)
+			testID++
+
+			// CREATE with a name longer than the column
+			_, err = rtc.Create(ts.ctx, employee.NewEmployee{Name: strings.Repeat("x", 57)}, now)
+			if dbErr := database.GetError(err); dbErr == nil || dbErr.Status != http.StatusBadRequest || dbErr.Field != "name" {
+				t.Fatalf("\t%s\tTest %d [Create]:\tExpecting a 400 on the name field : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d [Create]:\tExpecting a 400 on the name field", dbtest.Success, testID)
+
+		}
+	}
+}
+
+func Test_EmployeeHierarchy(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to work with reporting lines")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen handling a manager with two levels of reports.", testID)
+		{
+			now := time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+			root, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Root"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create the root : %s", dbtest.Failed, testID, err)
+			}
+			lead, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Lead", ManagerID: &root.ID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create the lead : %s", dbtest.Failed, testID, err)
+			}
+			dev, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Developer", ManagerID: &lead.ID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create the developer : %s", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a reporting line", dbtest.Success, testID)
+			testID++
+
+			// DIRECT REPORTS
+			direct, err := rsc.Reports(ts.ctx, root.ID, 1)
+			if err != nil || len(direct) != 1 || direct[0].ID != lead.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the direct reports : %v %v", dbtest.Failed, testID, direct, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the direct reports", dbtest.Success, testID)
+			testID++
+
+			// TRANSITIVE REPORTS
+			all, err := rsc.Reports(ts.ctx, root.ID, 2)
+			if err != nil || len(all) != 2 || all[1].ID != dev.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the transitive reports : %v %v", dbtest.Failed, testID, all, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the transitive reports", dbtest.Success, testID)
+			testID++
+
+			// CHAIN
+			chain, err := rsc.Chain(ts.ctx, dev.ID)
+			if err != nil || len(chain) != 2 || chain[0].ID != lead.ID || chain[1].ID != root.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the management chain : %v %v", dbtest.Failed, testID, chain, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the management chain", dbtest.Success, testID)
+			testID++
+
+			// CYCLE
+			_, err = rsc.Update(ts.ctx, root.ID, employee.UpdateEmployee{ManagerID: &dev.ID}, nil, now)
+			if !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to create a reporting cycle : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to create a reporting cycle", dbtest.Success, testID)
+			testID++
+
+			// ORG CHART
+			tree, err := rsc.OrgChart(ts.ctx, root.ID, employee.MaxDepth)
+			if err != nil || len(tree) != 1 || len(tree[0].Reports) != 1 || len(tree[0].Reports[0].Reports) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the nested org chart : %v %v", dbtest.Failed, testID, tree, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the nested org chart", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeConcurrentUpdates(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to update Employees from concurrent requests")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen two employees are made each other's manager at once.", testID)
+		{
+			now := time.Now().UTC()
+
+			a, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Concurrent A"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+			b, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Concurrent B"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i, pair := range [][2]string{{a.ID, b.ID}, {b.ID, a.ID}} {
+				wg.Add(1)
+				go func(i int, id string, managerID string) {
+					defer wg.Done()
+					_, errs[i] = rsc.Update(ts.ctx, id, employee.UpdateEmployee{ManagerID: &managerID}, nil, now)
+				}(i, pair[0], pair[1])
+			}
+			wg.Wait()
+
+			if (errs[0] == nil) == (errs[1] == nil) {
+				t.Fatalf("\t%s\tTest %d:\tShould apply exactly one of the updates : %v", dbtest.Failed, testID, errs)
+			}
+			for _, err := range errs {
+				if err != nil && !validate.IsFieldErrors(err) {
+					t.Fatalf("\t%s\tTest %d:\tShould refuse the other update as a cycle : %v", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould apply exactly one of the updates", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeHistory(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to know how an Employee changed")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen an employee is created, promoted and deleted.", testID)
+		{
+			now := time.Now().UTC()
+
+			emp, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "History", Position: "Senior Software Engineer"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+			us := employee.UpdateEmployee{Position: dbtest.StringPointer("Staff Engineer")}
+			if _, err := rsc.Update(ts.ctx, emp.ID, us, nil, now.Add(time.Second)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update Employee : %s", dbtest.Failed, testID, err)
+			}
+			if err := rsc.Delete(ts.ctx, emp.ID, nil, now.Add(2*time.Second)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete Employee : %s", dbtest.Failed, testID, err)
+			}
+
+			pagi := database.NewPagination()
+			pagi.Direction = "asc"
+			changes, total, err := rsc.History(ts.ctx, emp.PublicID, pagi)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to get the history of a deleted Employee : %s", dbtest.Failed, testID, err)
+			}
+			var actions []string
+			for _, c := range changes {
+				if c.EmployeeID != emp.ID || c.ID == "" || c.CreatedOn.IsZero() {
+					t.Fatalf("\t%s\tTest %d:\tShould get the change of the Employee : %+v", dbtest.Failed, testID, c)
+				}
+				actions = append(actions, c.Action)
+			}
+			want := []string{employee.ChangeCreate, employee.ChangeUpdate, employee.ChangeDelete}
+			if diff := cmp.Diff(want, actions); diff != "" || total != 3 {
+				t.Fatalf("\t%s\tTest %d:\tShould get every change in order, total %d. Diff:\n%s", dbtest.Failed, testID, total, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get every change in order", dbtest.Success, testID)
+			testID++
+
+			var fields map[string]employee.FieldChange
+			if err := json.Unmarshal(changes[1].Diff, &fields); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould decode the diff : %s", dbtest.Failed, testID, err)
+			}
+			if fc, ok := fields["position"]; !ok || fc.From != "Senior Software Engineer" || fc.To != "Staff Engineer" {
+				t.Fatalf("\t%s\tTest %d:\tShould record the position change : %s", dbtest.Failed, testID, changes[1].Diff)
+			}
+			if _, ok := fields["version"]; ok {
+				t.Fatalf("\t%s\tTest %d:\tShould leave the version out of the diff : %s", dbtest.Failed, testID, changes[1].Diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record the position change", dbtest.Success, testID)
+			testID++
+
+			_, _, err = rsc.History(ts.ctx, "923498273", pagi)
+			if !errors.Is(err, employee.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT get the history of a non-existing Employee : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT get the history of a non-existing Employee", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeAsOf(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to read Employees as they were in the past")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen an employee is created, promoted and deleted.", testID)
+		{
+			created := time.Date(2022, time.January, 10, 9, 0, 0, 0, time.UTC)
+			promoted := created.AddDate(0, 1, 0)
+			deleted := created.AddDate(0, 2, 0)
+
+			emp, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "AsOf Payroll", Position: "Senior Software Engineer"}, created)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+			us := employee.UpdateEmployee{Position: dbtest.StringPointer("Staff Engineer")}
+			if _, err := rsc.Update(ts.ctx, emp.ID, us, nil, promoted); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update Employee : %s", dbtest.Failed, testID, err)
+			}
+			if err := rsc.Delete(ts.ctx, emp.ID, nil, deleted); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete Employee : %s", dbtest.Failed, testID, err)
+			}
+
+			positions := []struct {
+				asOf time.Time
+				want string
+			}{
+				{created, "Senior Software Engineer"},
+				{promoted.Add(-time.Second), "Senior Software Engineer"},
+				{promoted, "Staff Engineer"},
+				{deleted.Add(-time.Second), "Staff Engineer"},
+			}
+			for _, p := range positions {
+				got, err := rsc.QueryByIDAsOf(ts.ctx, emp.PublicID, p.asOf)
+				if err != nil || got.Position != p.want {
+					t.Fatalf("\t%s\tTest %d:\tShould get position %q as of %s : %q %v", dbtest.Failed, testID, p.want, p.asOf, got.Position, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the position held at each instant", dbtest.Success, testID)
+			testID++
+
+			for _, asOf := range []time.Time{created.Add(-time.Second), deleted} {
+				if _, err := rsc.QueryByIDAsOf(ts.ctx, emp.ID, asOf); !errors.Is(err, employee.ErrNotFound) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT find the Employee as of %s : %v", dbtest.Failed, testID, asOf, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT find the Employee before creation or after deletion", dbtest.Success, testID)
+			testID++
+
+			filter := employee.QueryFilter{NamePrefix: dbtest.StringPointer("AsOf Payroll"), Position: dbtest.StringPointer("staff engineer")}
+			rs, total, err := rsc.QueryAsOf(ts.ctx, filter, database.NewPagination(), promoted)
+			if err != nil || total != 1 || len(rs) != 1 || rs[0].ID != emp.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould list the deleted Employee as it was : %v %d %v", dbtest.Failed, testID, rs, total, err)
+			}
+			_, total, err = rsc.QueryAsOf(ts.ctx, filter, database.NewPagination(), deleted)
+			if err != nil || total != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the Employee once deleted : %d %v", dbtest.Failed, testID, total, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the Employee while it existed", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeePendingChanges(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to record Employee changes ahead of time")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen a promotion is recorded before it takes effect.", testID)
+		{
+			now := time.Now().UTC().Truncate(time.Second)
+			effectiveOn := now.Add(time.Hour)
+
+			emp, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: "Pending Promotion", Position: "Senior Software Engineer"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+
+			us := employee.UpdateEmployee{Position: dbtest.StringPointer("Staff Engineer"), EffectiveOn: &now}
+			if _, err := rsc.Schedule(ts.ctx, emp.ID, us, now); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT schedule a change effective now : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT schedule a change effective now", dbtest.Success, testID)
+			testID++
+
+			us.EffectiveOn = &effectiveOn
+			pc, err := rsc.Schedule(ts.ctx, emp.PublicID, us, now)
+			if err != nil || pc.Status != employee.PendingStatusPending || validate.CheckUUID(pc.ID) != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to schedule the change under a public ID : %v %v", dbtest.Failed, testID, pc, err)
+			}
+			pending, err := rsc.PendingChanges(ts.ctx, emp.ID)
+			if err != nil || len(pending) != 1 || pending[0].ID != pc.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould list the pending change : %v %v", dbtest.Failed, testID, pending, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to schedule and list the change", dbtest.Success, testID)
+			testID++
+
+			if _, err := rsc.ApplyPendingChanges(ts.ctx, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to apply due changes : %s", dbtest.Failed, testID, err)
+			}
+			got, err := rsc.QueryByID(ts.ctx, emp.ID)
+			if err != nil || got.Position != "Senior Software Engineer" {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT apply the change early : %q %v", dbtest.Failed, testID, got.Position, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT apply the change early", dbtest.Success, testID)
+			testID++
+
+			// The scheduler passes after the effective date, the change is
+			// recorded at the time it is applied.
+			applied := effectiveOn.Add(time.Minute)
+			if _, err := rsc.ApplyPendingChanges(ts.ctx, applied); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to apply due changes : %s", dbtest.Failed, testID, err)
+			}
+			got, err = rsc.QueryByID(ts.ctx, emp.ID)
+			if err != nil || got.Position != "Staff Engineer" || !got.UpdatedOn.Equal(applied) {
+				t.Fatalf("\t%s\tTest %d:\tShould apply the change on its date : %v %v", dbtest.Failed, testID, got, err)
+			}
+			changes, _, err := rsc.History(ts.ctx, emp.ID, database.NewPagination())
+			if err != nil || len(changes) != 2 || changes[0].Action != employee.ChangeUpdate {
+				t.Fatalf("\t%s\tTest %d:\tShould audit the applied change : %v %v", dbtest.Failed, testID, changes, err)
+			}
+			pending, err = rsc.PendingChanges(ts.ctx, emp.ID)
+			if err != nil || len(pending) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould no longer list the applied change : %v %v", dbtest.Failed, testID, pending, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould apply the change on its date", dbtest.Success, testID)
+			testID++
+
+			pc, err = rsc.Schedule(ts.ctx, emp.ID, us, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to schedule the change : %s", dbtest.Failed, testID, err)
+			}
+			err = rsc.CancelPendingChange(ts.ctx, emp.ID, "1", now)
+			if !errors.Is(err, employee.ErrInvalidID) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT cancel the change by a sequential ID : %v", dbtest.Failed, testID, err)
+			}
+			if err := rsc.CancelPendingChange(ts.ctx, emp.ID, pc.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to cancel the change : %s", dbtest.Failed, testID, err)
+			}
+			err = rsc.CancelPendingChange(ts.ctx, emp.ID, pc.ID, now)
+			if !errors.Is(err, employee.ErrPendingChangeNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT cancel the change twice : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to cancel the change", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeBatch(t *testing.T) {
+	registerTestSuite(t)
 
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to apply many Employee operations at once")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen a batch holds a failing operation.", testID)
+		{
+			now := time.Now().UTC()
+			name := "Batch Onboarding"
+			count := func() int {
+				total, err := rsc.Count(ts.ctx, employee.QueryFilter{NamePrefix: &name})
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to count Employees : %s", dbtest.Failed, testID, err)
+				}
+				return total
+			}
+			before := count()
+
+			if _, err := rsc.Batch(ts.ctx, employee.Batch{}, now); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept an empty batch : %v", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept an empty batch", dbtest.Success, testID)
+			testID++
+
+			b := employee.Batch{
+				Atomic: true,
+				Operations: []employee.BatchOperation{
+					{Op: employee.BatchCreate, Employee: &employee.NewEmployee{Name: name}},
+					{Op: employee.BatchDelete, ID: "923498273"},
+				},
+			}
+			_, err := rsc.Batch(ts.ctx, b, now)
+			var be *employee.BatchError
+			if !errors.As(err, &be) || be.Index != 1 || !errors.Is(err, employee.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould report the failing operation : %v", dbtest.Failed, testID, err)
+			}
+			if got := count(); got != before {
+				t.Fatalf("\t%s\tTest %d:\tShould apply nothing of an atomic batch, got %d employees, want %d", dbtest.Failed, testID, got, before)
+			}
+			t.Logf("\t%s\tTest %d:\tShould apply nothing of a failed atomic batch", dbtest.Success, testID)
+			testID++
+
+			b.Atomic = false
+			rs, err := rsc.Batch(ts.ctx, b, now)
+			if err != nil || len(rs) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to apply the batch : %v %v", dbtest.Failed, testID, rs, err)
+			}
+			if rs[0].Err != nil || rs[0].Employee == nil || !errors.Is(rs[1].Err, employee.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould report every operation : %v", dbtest.Failed, testID, rs)
+			}
+			if got := count(); got != before+1 {
+				t.Fatalf("\t%s\tTest %d:\tShould apply the valid operations, got %d employees, want %d", dbtest.Failed, testID, got, before+1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould apply the valid operations of a batch", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeFilter(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to filter Employee records")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen filtering on the name and the soft delete.", testID)
+		{
+			now := time.Now().UTC()
+			name := fmt.Sprintf("Filter%d", now.UnixNano())
+
+			rec, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: name + " Prasad"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create Employee : %s", dbtest.Failed, testID, err)
+			}
+			pagi := database.NewPagination()
+
+			// NAME PREFIX
+			res, _, err := rsc.Query(ts.ctx, employee.QueryFilter{NamePrefix: &name}, pagi)
+			if err != nil || len(res) != 1 || res[0].ID != rec.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould find the Employee by name prefix : %v %v", dbtest.Failed, testID, res, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the Employee by name prefix", dbtest.Success, testID)
+			testID++
+
+			// COUNT
+			total, err := rsc.Count(ts.ctx, employee.QueryFilter{NamePrefix: &name})
+			if err != nil || total != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould count a single Employee, got %d : %v", dbtest.Failed, testID, total, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count a single Employee", dbtest.Success, testID)
+			testID++
+
+			// WILDCARDS MATCH LITERALLY
+			wildcard := name[:4] + "%"
+			res, _, err = rsc.Query(ts.ctx, employee.QueryFilter{NameContains: &wildcard}, pagi)
+			if err != nil || len(res) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould match wildcards literally : %v %v", dbtest.Failed, testID, res, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould match wildcards literally", dbtest.Success, testID)
+			testID++
+
+			// INCLUDE DELETED
+			if err := rsc.Delete(ts.ctx, rec.ID, nil, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to soft delete Employee : %s", dbtest.Failed, testID, err)
+			}
+			res, _, err = rsc.Query(ts.ctx, employee.QueryFilter{NamePrefix: &name}, pagi)
+			if err != nil || len(res) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list deleted Employees by default : %v %v", dbtest.Failed, testID, res, err)
+			}
+			res, _, err = rsc.Query(ts.ctx, employee.QueryFilter{NamePrefix: &name, IncludeDeleted: true}, pagi)
+			if err != nil || len(res) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould list deleted Employees on request : %v %v", dbtest.Failed, testID, res, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list deleted Employees on request", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeImport(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to import Employees from a spreadsheet")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen the rows are imported with and without a dry run.", testID)
+		{
+			now := time.Now().UTC()
+			name := "Import Onboarding"
+			count := func() int {
+				total, err := rsc.Count(ts.ctx, employee.QueryFilter{NamePrefix: &name})
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to count Employees : %s", dbtest.Failed, testID, err)
+				}
+				return total
+			}
+			before := count()
+
+			missing := "923498273"
+			rows := []employee.ImportRow{
+				{Line: 2, Employee: employee.NewEmployee{Name: name}},
+				{Line: 3, Blank: true},
+				{Line: 4, Employee: employee.NewEmployee{Name: name, ManagerID: &missing}},
+				{Line: 5, Employee: employee.NewEmployee{}},
+			}
+
+			sum, err := rsc.Import(ts.ctx, rows, true, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to dry run the import : %s", dbtest.Failed, testID, err)
+			}
+			if !sum.DryRun || sum.Created != 1 || sum.Skipped != 1 || sum.Failed != 2 || len(sum.Rows) != 4 {
+				t.Fatalf("\t%s\tTest %d:\tShould report every row : %+v", dbtest.Failed, testID, sum)
+			}
+			if sum.Rows[0].Employee == nil || sum.Rows[0].Employee.ID != "" || sum.Rows[3].Fields["name"] == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould report the outcome of the rows : %+v", dbtest.Failed, testID, sum.Rows)
+			}
+			if got := count(); got != before {
+				t.Fatalf("\t%s\tTest %d:\tShould write nothing on a dry run, got %d employees, want %d", dbtest.Failed, testID, got, before)
+			}
+			t.Logf("\t%s\tTest %d:\tShould report the rows without writing on a dry run", dbtest.Success, testID)
+			testID++
+
+			sum, err = rsc.Import(ts.ctx, rows, false, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to import : %s", dbtest.Failed, testID, err)
+			}
+			if sum.Created != 1 || sum.Rows[0].Employee == nil || sum.Rows[0].Employee.ID == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould create the valid rows : %+v", dbtest.Failed, testID, sum)
+			}
+			if got := count(); got != before+1 {
+				t.Fatalf("\t%s\tTest %d:\tShould create the valid rows, got %d employees, want %d", dbtest.Failed, testID, got, before+1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould create the valid rows of an import", dbtest.Success, testID)
+		}
+	}
+}
+
+func Test_EmployeeExport(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	rsc := employee.NewCore(ts.log, ts.db)
+
+	t.Log("Given the need to export every matching Employee")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen the employees are exported with a filter.", testID)
+		{
+			name := "Export Onboarding"
+			total, err := rsc.Count(ts.ctx, employee.QueryFilter{NamePrefix: &name})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to count Employees : %s", dbtest.Failed, testID, err)
+			}
+
+			for i := 0; i < 3; i++ {
+				if _, err := rsc.Create(ts.ctx, employee.NewEmployee{Name: name}, time.Now().UTC()); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to create an Employee : %s", dbtest.Failed, testID, err)
+				}
+			}
+
+			var ids []int
+			err = rsc.Export(ts.ctx, employee.QueryFilter{NamePrefix: &name}, func(emp employee.Employee) error {
+				id, err := strconv.Atoi(emp.ID)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to export Employees : %s", dbtest.Failed, testID, err)
+			}
+			if len(ids) != total+3 || !slices.IsSorted(ids) {
+				t.Fatalf("\t%s\tTest %d:\tShould export every matching Employee in id order, got %v, want %d", dbtest.Failed, testID, ids, total+3)
+			}
+			t.Logf("\t%s\tTest %d:\tShould export every matching Employee in id order", dbtest.Success, testID)
+			testID++
+
+			stop := errors.New("stop")
+			calls := 0
+			err = rsc.Export(ts.ctx, employee.QueryFilter{NamePrefix: &name}, func(employee.Employee) error {
+				calls++
+				return stop
+			})
+			if !errors.Is(err, stop) || calls != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould stop at the first error : %v after %d calls", dbtest.Failed, testID, err, calls)
+			}
+			t.Logf("\t%s\tTest %d:\tShould stop the export at the first error", dbtest.Success, testID)
 		}
 	}
 }
@@ -0,0 +1,47 @@
+package employee
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/api"
+)
+
+// ETag is the strong entity tag of the employee, a digest of its
+// representation. Any change to what is sent, the title of the position
+// included, gives a new tag even when the version stays the same.
+func (e Employee) ETag() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return api.ETag(e.PublicID)
+	}
+	sum := sha256.Sum256(data)
+	return api.ETag(hex.EncodeToString(sum[:16]))
+}
+
+// Match is the state a change expects the employee to be in, checked in
+// the transaction of the change. A nil Match accepts any state.
+type Match func(Employee) bool
+
+// MatchVersion expects the employee at the version.
+func MatchVersion(version int) Match {
+	return func(e Employee) bool {
+		return e.Version == version
+	}
+}
+
+// MatchETag expects the employee to have one of the entity tags, "*" is
+// met by any employee. Tags compare strongly, weak tags never match.
+func MatchETag(tags ...string) Match {
+	return func(e Employee) bool {
+		return slices.Contains(tags, "*") || slices.Contains(tags, e.ETag())
+	}
+}
+
+// met reports whether the employee is in the expected state.
+func (m Match) met(dbRS db.Employee) bool {
+	return m == nil || m(toEmployee(dbRS))
+}
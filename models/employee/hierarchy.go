@@ -0,0 +1,167 @@
+package employee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// MaxDepth is the deepest reporting line walked when listing reports,
+// management chains or the org chart.
+const MaxDepth = 32
+
+// ErrInvalidDepth is returned when the requested depth is out of range.
+var ErrInvalidDepth = fmt.Errorf("depth must be between 1 and %d", MaxDepth)
+
+// OrgNode is an employee in the org chart together with their reports.
+//
+//swagger:model OrgNode
+type OrgNode struct {
+	Employee
+	// Employees reporting to this employee
+	Reports []OrgNode `json:"reports"`
+}
+
+// Reports retrieves the employees reporting to the employee, the direct
+// reports for a depth of 1 and the transitive ones for larger depths.
+func (c Core) Reports(ctx context.Context, id string, depth int) ([]Employee, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, ErrInvalidDepth
+	}
+
+	emp, err := c.QueryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.store.QueryReports(ctx, emp.ID, depth)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toEmployeeSlice(res), nil
+}
+
+// Chain retrieves the management chain of the employee, starting with the
+// direct manager and ending with the root of the organization.
+func (c Core) Chain(ctx context.Context, id string) ([]Employee, error) {
+	emp, err := c.QueryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.store.QueryChain(ctx, emp.ID, MaxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toEmployeeSlice(res), nil
+}
+
+// OrgChart builds the reporting tree. With an empty root every employee
+// without an active manager starts a tree, otherwise only the tree below
+// root is returned. Depth limits the levels below the roots.
+func (c Core) OrgChart(ctx context.Context, root string, depth int) ([]OrgNode, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, ErrInvalidDepth
+	}
+
+	var roots []Employee
+	if root != "" {
+		emp, err := c.QueryByID(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, emp)
+	}
+
+	res, err := c.store.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	all := toEmployeeSlice(res)
+
+	active := make(map[string]bool, len(all))
+	for _, e := range all {
+		active[e.ID] = true
+	}
+
+	reports := make(map[string][]Employee)
+	for _, e := range all {
+		if e.ManagerID == nil || !active[*e.ManagerID] {
+			if root == "" {
+				roots = append(roots, e)
+			}
+			continue
+		}
+		reports[*e.ManagerID] = append(reports[*e.ManagerID], e)
+	}
+
+	var build func(e Employee, level int) OrgNode
+	build = func(e Employee, level int) OrgNode {
+		node := OrgNode{Employee: e, Reports: []OrgNode{}}
+		if level >= depth {
+			return node
+		}
+		for _, r := range reports[e.ID] {
+			node.Reports = append(node.Reports, build(r, level+1))
+		}
+		return node
+	}
+
+	nodes := make([]OrgNode, len(roots))
+	for i, e := range roots {
+		nodes[i] = build(e, 0)
+	}
+
+	return nodes, nil
+}
+
+// checkManager validates that the manager exists and, for an existing
+// employee, that assigning it does not create a reporting cycle.
+func (c Core) checkManager(ctx context.Context, store db.Store, id string, managerID *string) error {
+	if managerID == nil {
+		return nil
+	}
+
+	fieldErr := func(msg string) error {
+		return validate.FieldErrors{
+			FieldError: []validate.FieldError{{Field: "manager_id", Error: msg}},
+		}
+	}
+
+	if err := validate.CheckID(*managerID); err != nil {
+		return fieldErr(ErrInvalidManager.Error())
+	}
+	if *managerID == id {
+		return fieldErr("employee cannot be their own manager")
+	}
+
+	if _, err := store.QueryByID(ctx, *managerID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fieldErr("manager does not exist")
+		}
+		return fmt.Errorf("checking manager id[%s]: %w", *managerID, err)
+	}
+
+	// New employees cannot be anybody's manager yet.
+	if id == "" {
+		return nil
+	}
+
+	chain, err := store.QueryChain(ctx, *managerID, MaxDepth)
+	if err != nil {
+		return fmt.Errorf("checking manager id[%s]: %w", *managerID, err)
+	}
+	for _, m := range chain {
+		if m.ID == id {
+			return fieldErr("manager would create a reporting cycle")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package employee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// MaxImportRows is the largest number of rows in an import.
+const MaxImportRows = 5000
+
+// Set of outcomes of an imported row.
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// ImportRow is a row of a spreadsheet to import. Blank rows are skipped.
+type ImportRow struct {
+	Line     int
+	Blank    bool
+	Employee NewEmployee
+}
+
+// ImportSummary is the outcome of an import.
+//
+//swagger:model ImportSummary
+type ImportSummary struct {
+	// Nothing was written
+	// example: false
+	DryRun bool `json:"dry_run"`
+	// Rows created, or that would be on a dry run
+	// example: 24
+	Created int `json:"created"`
+	// Blank rows
+	// example: 1
+	Skipped int `json:"skipped"`
+	// Rows that cannot be imported
+	// example: 2
+	Failed int `json:"failed"`
+	// The outcome of every row
+	Rows []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult is the outcome of a row of an import.
+//
+//swagger:model ImportRowResult
+type ImportRowResult struct {
+	// Line of the row in the file, the header being line 1
+	// example: 2
+	Line int `json:"line"`
+	// One of created, skipped or failed
+	// example: created
+	Status string `json:"status"`
+	// The employee created, without ids on a dry run
+	Employee *Employee `json:"employee,omitempty"`
+	// Why the row cannot be imported
+	// example: data validation error
+	Error string `json:"error,omitempty"`
+	// Field errors of the row
+	// example: {"name": "name is a required field"}
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Import creates an employee for every valid row in a single transaction,
+// rows that fail validation or reference missing records are reported and
+// left out. A dry run goes through the same checks and inserts, then rolls
+// them back.
+func (c Core) Import(ctx context.Context, rows []ImportRow, dryRun bool, now time.Time) (ImportSummary, error) {
+	if len(rows) > MaxImportRows {
+		return ImportSummary{}, validate.FieldErrors{
+			FieldError: []validate.FieldError{{Field: "rows", Error: fmt.Sprintf("at most %d rows are allowed", MaxImportRows)}},
+		}
+	}
+
+	var sum ImportSummary
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		sum = ImportSummary{DryRun: dryRun, Rows: make([]ImportRowResult, len(rows))}
+		for i, row := range rows {
+			res := ImportRowResult{Line: row.Line}
+			switch {
+			case row.Blank:
+				res.Status = ImportSkipped
+				sum.Skipped++
+
+			default:
+				emp, err := c.importRow(ctx, store, row.Employee, now)
+				if err != nil {
+					if !rowError(err, &res) {
+						return fmt.Errorf("line %d: %w", row.Line, err)
+					}
+					res.Status = ImportFailed
+					sum.Failed++
+					break
+				}
+				if dryRun {
+					emp.ID, emp.PublicID = "", ""
+				}
+				res.Status = ImportCreated
+				res.Employee = &emp
+				sum.Created++
+			}
+			sum.Rows[i] = res
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, serializable, tran); err != nil && !errors.Is(err, errDryRun) {
+		return ImportSummary{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return sum, nil
+}
+
+// importRow validates and creates the employee of a row.
+func (c Core) importRow(ctx context.Context, store db.Store, ne NewEmployee, now time.Time) (Employee, error) {
+	if err := validate.Check(ne); err != nil {
+		return Employee{}, err
+	}
+	return c.create(ctx, store, ne, now)
+}
+
+// rowError reports the error of a row the client can fix in res. It
+// returns false for unexpected errors, which abort the import.
+func rowError(err error, res *ImportRowResult) bool {
+	switch {
+	case validate.IsFieldErrors(err):
+		res.Error = validate.GetCustomError(err)
+		if res.Error == "" {
+			res.Error = "data validation error"
+		}
+		res.Fields = validate.GetFieldErrors(err).Fields()
+		return true
+
+	case database.IsError(err):
+		dbErr := database.GetError(err)
+		if dbErr.Status >= 500 {
+			return false
+		}
+		res.Error = dbErr.Error()
+		if dbErr.Field != "" {
+			res.Fields = map[string]string{dbErr.Field: dbErr.Error()}
+		}
+		return true
+	}
+
+	return false
+}
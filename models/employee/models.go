@@ -4,24 +4,40 @@ import (
 	"context"
 	"fmt"
 	"time"
-	"unsafe"
 
 	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/database"
 )
 
 // Employee holds the employee information.
 //
 //swagger:model Employee
 type Employee struct {
+	// Public identifier, accepted by every route in place of the id
+	// example: 01906a4e-8c2b-7b3e-9f4a-2d5c6e7f8a9b
+	PublicID string `json:"public_id"`
 	// Primary Key
 	// example: 1
 	ID string `json:"id"`
 	// Employee Name
 	// example: Sachin Prasad
 	Name string `json:"name"`
-	// Employee designation
+	// Employee designation, the title of the catalog position
 	// example: Senior Software Engineer
 	Position string `json:"position"`
+	// Catalog position of the employee
+	// example: 1
+	PositionID *string `json:"position_id"`
+	// Department the employee is assigned to
+	// example: 1
+	DepartmentID *string `json:"department_id"`
+	// Manager the employee reports to
+	// example: 3
+	ManagerID *string `json:"manager_id"`
+	// Revision of the record, increased by every change. Batch operations
+	// expect it back like If-Match expects the ETag.
+	// example: 3
+	Version int `json:"version"`
 	// Database created value
 	// example: 2021-05-25T00:53:16.535668Z
 	CreatedOn time.Time `json:"created_on"`
@@ -43,10 +59,23 @@ type NewEmployee struct {
 	// required: true
 	// example: Sachin Prasad
 	Name string `json:"name" validate:"required,notblank"`
-	// Employee Designamtion
+	// Title of a catalog position, kept for backward compatibility. A
+	// title not in the catalog yet is added to it. Prefer position_id.
 	// in: string
 	// example: Senior Software Engineer
-	Position string `json:"position"`
+	Position string `json:"position" validate:"max=56"`
+	// Catalog position of the employee
+	// in: string
+	// example: 1
+	PositionID *string `json:"position_id"`
+	// Department the employee is assigned to
+	// in: string
+	// example: 1
+	DepartmentID *string `json:"department_id"`
+	// Manager the employee reports to
+	// in: string
+	// example: 3
+	ManagerID *string `json:"manager_id"`
 }
 
 // UpdateEmployee defines what information may be provided to
@@ -59,17 +88,95 @@ type NewEmployee struct {
 //
 //swagger:model UpdateEmployee
 type UpdateEmployee struct {
-	// Employee Designamtion
+	// Title of a catalog position, kept for backward compatibility. A
+	// title not in the catalog yet is added to it. Prefer position_id.
+	// An empty value removes the position.
+	// in: string
+	// example: Staff Engineer
+	Position *string `json:"position" validate:"omitempty,max=56"`
+	// Catalog position of the employee, an empty value removes the position
+	// in: string
+	// example: 2
+	PositionID *string `json:"position_id"`
+	// Department the employee is assigned to
 	// in: string
-	// example: Staff Software Engineer
-	Position *string `json:"position"`
+	// example: 2
+	DepartmentID *string `json:"department_id"`
+	// Manager the employee reports to, an empty value removes the manager
+	// in: string
+	// example: 3
+	ManagerID *string `json:"manager_id"`
+	// When the change takes effect. A future date records the change as
+	// pending, it is applied when the date arrives.
+	// in: string
+	// example: 2026-11-01T00:00:00Z
+	EffectiveOn *time.Time `json:"effective_on,omitempty"`
+}
+
+// QueryFilter holds the optional filters for listing employees.
+type QueryFilter struct {
+	DepartmentID   *string
+	NamePrefix     *string
+	NameContains   *string
+	Position       *string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	IncludeDeleted bool
 }
 
 // =============================================================================
 
 func toEmployee(dbRS db.Employee) Employee {
-	p := (*Employee)(unsafe.Pointer(&dbRS))
-	return *p
+	return Employee{
+		PublicID:     dbRS.PublicID,
+		ID:           dbRS.ID,
+		Name:         dbRS.Name,
+		Position:     dbRS.Position,
+		PositionID:   dbRS.PositionID,
+		DepartmentID: dbRS.DepartmentID,
+		ManagerID:    dbRS.ManagerID,
+		Version:      dbRS.Version,
+		CreatedOn:    dbRS.CreatedOn,
+		UpdatedOn:    dbRS.UpdatedOn,
+		DeletedOn:    dbRS.DeletedOn,
+	}
+}
+
+func toDBQueryFilter(filter QueryFilter) db.QueryFilter {
+	return db.QueryFilter{
+		DepartmentID:   filter.DepartmentID,
+		NamePrefix:     filter.NamePrefix,
+		NameContains:   filter.NameContains,
+		Position:       filter.Position,
+		CreatedAfter:   filter.CreatedAfter,
+		CreatedBefore:  filter.CreatedBefore,
+		UpdatedAfter:   filter.UpdatedAfter,
+		IncludeDeleted: filter.IncludeDeleted,
+	}
+}
+
+// cursorAt returns the position of the employee in a listing sorted on
+// the column.
+func cursorAt(e Employee, sort string) database.Cursor {
+	c := database.Cursor{ID: e.ID}
+	switch sort {
+	case "created_on":
+		c.Value = database.CursorValue(e.CreatedOn)
+	case "updated_on":
+		c.Value = database.CursorValue(e.UpdatedOn)
+	default:
+		c.Value = e.ID
+	}
+	return c
+}
+
+// positionID returns the id of the resolved position, nil for none.
+func positionID(pos db.Position) *string {
+	if pos.ID == "" {
+		return nil
+	}
+	return &pos.ID
 }
 
 func toEmployeeSlice(dbSRs []db.Employee) []Employee {
@@ -101,11 +208,18 @@ func (nrt NewEmployee) fakeData(counter int) NewEmployee {
 	}
 }
 
-// Seed runs create methods from an array of new values
+// Seed creates the new values through atomic batches of at most MaxBatch
+// operations.
 func (c Core) Seed(ctx context.Context, data []NewEmployee) error {
 	now := time.Now().UTC()
-	for _, ns := range data {
-		if _, err := c.Create(ctx, ns, now); err != nil {
+	for start := 0; start < len(data); start += MaxBatch {
+		end := min(start+MaxBatch, len(data))
+
+		b := Batch{Atomic: true}
+		for i := start; i < end; i++ {
+			b.Operations = append(b.Operations, BatchOperation{Op: BatchCreate, Employee: &data[i]})
+		}
+		if _, err := c.Batch(ctx, b, now); err != nil {
 			return fmt.Errorf("error seeding status: %w", err)
 		}
 	}
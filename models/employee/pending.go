@@ -0,0 +1,299 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/employee/db"
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// Set of statuses of a pending change.
+const (
+	PendingStatusPending   = "pending"
+	PendingStatusApplied   = "applied"
+	PendingStatusCancelled = "cancelled"
+	PendingStatusFailed    = "failed"
+)
+
+// ErrPendingChangeNotFound is returned when the employee has no such
+// pending change.
+var ErrPendingChangeNotFound = errors.New("pending change not found")
+
+// maxPendingError is the length of the error kept on a failed change.
+const maxPendingError = 512
+
+// maxPendingAttempts is how many times a change failing for another reason
+// than being invalid is tried, one pass apart, before it is marked failed.
+const maxPendingAttempts = 5
+
+// PendingChange is a change of an employee waiting for its effective date.
+//
+//swagger:model PendingChange
+type PendingChange struct {
+	// Public identifier, the sequential ids are never exposed
+	// example: 01906a4f-1c2d-7e3f-8a4b-5c6d7e8f9a0b
+	ID string `json:"id"`
+	// Employee to change
+	// example: 1
+	EmployeeID string `json:"employee_id"`
+	// The changes to apply
+	Changes UpdateEmployee `json:"changes"`
+	// When the change takes effect
+	// example: 2026-11-01T00:00:00Z
+	EffectiveOn time.Time `json:"effective_on"`
+	// One of pending, applied, cancelled or failed
+	// example: pending
+	Status string `json:"status"`
+	// Why the change could not be applied, or the last attempt failed
+	Error string `json:"error,omitempty"`
+	// Failed attempts at applying the change
+	// example: 0
+	Attempts int `json:"attempts"`
+	// Caller recording the change
+	// example: jane.doe
+	Actor string `json:"actor"`
+	// Database created value
+	// example: 2021-05-25T00:53:16.535668Z
+	CreatedOn time.Time `json:"created_on"`
+	// Database last updated value
+	// example: 2021-05-25T00:53:16.535668Z
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// Schedule records the changes to apply to the employee on
+// urs.EffectiveOn, which must be later than now. The references are
+// checked now and again when the change is applied.
+func (c Core) Schedule(ctx context.Context, id string, urs UpdateEmployee, now time.Time) (PendingChange, error) {
+	if err := validate.Check(urs); err != nil {
+		return PendingChange{}, err
+	}
+	if urs.EffectiveOn == nil || !urs.EffectiveOn.After(now) {
+		return PendingChange{}, validate.FieldErrors{
+			FieldError: []validate.FieldError{{Field: "effective_on", Error: "effective_on must be in the future"}},
+		}
+	}
+
+	emp, err := c.QueryByID(ctx, id)
+	if err != nil {
+		return PendingChange{}, err
+	}
+
+	if urs.Position != nil || urs.PositionID != nil {
+		if _, err := c.findPosition(ctx, c.store, urs.Position, urs.PositionID); err != nil {
+			return PendingChange{}, err
+		}
+	}
+	if err := c.checkDepartment(ctx, c.store, urs.DepartmentID); err != nil {
+		return PendingChange{}, err
+	}
+	if urs.ManagerID != nil && *urs.ManagerID != "" {
+		if err := c.checkManager(ctx, c.store, emp.ID, urs.ManagerID); err != nil {
+			return PendingChange{}, err
+		}
+	}
+
+	effectiveOn := urs.EffectiveOn.UTC()
+	urs.EffectiveOn = nil
+	changes, err := json.Marshal(urs)
+	if err != nil {
+		return PendingChange{}, fmt.Errorf("encoding changes: %w", err)
+	}
+
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return PendingChange{}, fmt.Errorf("generating public id: %w", err)
+	}
+
+	dbPC := db.PendingChange{
+		PublicID:    publicID.String(),
+		EmployeeID:  emp.ID,
+		Changes:     changes,
+		EffectiveOn: effectiveOn,
+		Status:      PendingStatusPending,
+		Actor:       api.GetPrincipal(ctx).Subject,
+		CreatedOn:   now,
+		UpdatedOn:   now,
+	}
+
+	res, err := c.store.CreatePendingChange(ctx, dbPC)
+	if err != nil {
+		return PendingChange{}, fmt.Errorf("create: %w", err)
+	}
+	dbPC.ID = fmt.Sprintf("%d", res.LastInsertID)
+
+	return toPendingChange(dbPC)
+}
+
+// PendingChanges retrieves the changes of the employee waiting for their
+// effective date, the earliest first.
+func (c Core) PendingChanges(ctx context.Context, id string) ([]PendingChange, error) {
+	emp, err := c.QueryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.store.QueryPendingChanges(ctx, emp.ID, PendingStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	pcs := make([]PendingChange, len(res))
+	for i, dbPC := range res {
+		if pcs[i], err = toPendingChange(dbPC); err != nil {
+			return nil, err
+		}
+	}
+
+	return pcs, nil
+}
+
+// CancelPendingChange withdraws a change of the employee that has not been
+// applied yet. The change is addressed by its public id.
+func (c Core) CancelPendingChange(ctx context.Context, id string, changeID string, now time.Time) error {
+	if err := validate.CheckUUID(changeID); err != nil {
+		return ErrInvalidID
+	}
+
+	emp, err := c.QueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = c.store.UpdatePendingChangeStatus(ctx, emp.ID, changeID, PendingStatusPending, PendingStatusCancelled, "", now)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrPendingChangeNotFound
+		}
+		return fmt.Errorf("cancel: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyPendingChanges applies every pending change effective at or before
+// now and returns how many were processed. Each change goes through the
+// same path as Update, in a transaction also recording its new status, and
+// is audited as made at now by the caller who scheduled it. The change
+// keeps its effective date. Changes that are no longer valid are marked
+// failed with the reason, the ones failing otherwise are tried again on
+// the next passes and marked failed after maxPendingAttempts, so they do
+// not hold up the changes due after them.
+func (c Core) ApplyPendingChanges(ctx context.Context, now time.Time) (int, error) {
+	var processed int
+
+	// Changes up to the last one failing in this pass are left for the
+	// next pass.
+	var failed db.PendingChange
+	for ctx.Err() == nil {
+		done := false
+		var dbPC db.PendingChange
+
+		tran := func(tx sqlx.ExtContext) error {
+			store := c.store.Tran(tx)
+
+			var err error
+			dbPC, err = store.QueryDuePendingChange(ctx, PendingStatusPending, now, failed)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					done = true
+					return nil
+				}
+				return err
+			}
+
+			v := api.ContextValues{
+				TracerUID: api.GetTracerUID(ctx),
+				Now:       now,
+				Principal: api.Principal{Subject: dbPC.Actor, Method: "scheduler"},
+			}
+			actx := api.WithValues(ctx, &v)
+
+			var urs UpdateEmployee
+			err = json.Unmarshal(dbPC.Changes, &urs)
+			if err == nil {
+				err = validate.Check(urs)
+			}
+			if err == nil {
+				_, err = c.update(actx, store, dbPC.EmployeeID, urs, nil, now)
+			}
+
+			status, msg := PendingStatusApplied, ""
+			if err != nil {
+				if !invalidChange(err) {
+					return err
+				}
+				status, msg = PendingStatusFailed, truncateError(err)
+			}
+
+			return store.UpdatePendingChangeStatus(ctx, dbPC.EmployeeID, dbPC.PublicID, PendingStatusPending, status, msg, now)
+		}
+
+		err := c.store.WithinTran(ctx, serializable, tran)
+		switch {
+		case err != nil && (dbPC.ID == "" || ctx.Err() != nil):
+			return processed, fmt.Errorf("tran: %w", err)
+		case err != nil:
+			err = c.store.RecordPendingChangeAttempt(ctx, dbPC.ID, PendingStatusPending, PendingStatusFailed, truncateError(err), maxPendingAttempts, now)
+			if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+				return processed, fmt.Errorf("attempt: %w", err)
+			}
+			failed = dbPC
+		case done:
+			return processed, nil
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// truncateError returns the message of the error cut to what a change
+// keeps.
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxPendingError {
+		msg = msg[:maxPendingError]
+	}
+	return msg
+}
+
+// invalidChange tells whether the error makes the change impossible to
+// apply, as opposed to a failure worth retrying.
+func invalidChange(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return validate.IsFieldErrors(err) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr)
+}
+
+// toPendingChange decodes the changes of the pending change.
+func toPendingChange(dbPC db.PendingChange) (PendingChange, error) {
+	pc := PendingChange{
+		ID:          dbPC.PublicID,
+		EmployeeID:  dbPC.EmployeeID,
+		EffectiveOn: dbPC.EffectiveOn,
+		Status:      dbPC.Status,
+		Error:       dbPC.Error,
+		Attempts:    dbPC.Attempts,
+		Actor:       dbPC.Actor,
+		CreatedOn:   dbPC.CreatedOn,
+		UpdatedOn:   dbPC.UpdatedOn,
+	}
+	if err := json.Unmarshal(dbPC.Changes, &pc.Changes); err != nil {
+		return PendingChange{}, fmt.Errorf("decoding changes of pending change public id[%s]: %w", dbPC.PublicID, err)
+	}
+	pc.Changes.EffectiveOn = &pc.EffectiveOn
+
+	return pc, nil
+}
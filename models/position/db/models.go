@@ -0,0 +1,17 @@
+package db
+
+import (
+	"time"
+)
+
+// Position represent the structure we need for moving data
+// between the app and the database.
+type Position struct {
+	ID        string     `db:"id"`
+	Title     string     `db:"title"`
+	JobFamily string     `db:"job_family"`
+	Level     int        `db:"level"`
+	CreatedOn time.Time  `db:"created_on"`
+	UpdatedOn time.Time  `db:"updated_on"`
+	DeletedOn *time.Time `db:"deleted_on"`
+}
@@ -0,0 +1,133 @@
+package position
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pansachin/employee-service/models/position/db"
+)
+
+// Position holds the position information.
+//
+//swagger:model Position
+type Position struct {
+	// Primary Key
+	// example: 1
+	ID string `json:"id"`
+	// Position Title
+	// example: Senior Software Engineer
+	Title string `json:"title"`
+	// Job family the position belongs to
+	// example: Engineering
+	JobFamily string `json:"job_family"`
+	// Level of the position within the job family
+	// example: 3
+	Level int `json:"level"`
+	// Database created value
+	// example: 2021-05-25T00:53:16.535668Z
+	CreatedOn time.Time `json:"created_on"`
+	// Database last updated value
+	// example: 2021-05-25T00:53:16.535668Z
+	UpdatedOn time.Time `json:"updated_on"`
+	// Database soft delete value
+	// example: 2021-05-25T00:53:16.535668Z
+	// swagger:ignore
+	DeletedOn *time.Time `json:"deleted_on,omitempty"`
+}
+
+// NewPosition defines the model of adding new position.
+//
+//swagger:model NewPosition
+type NewPosition struct {
+	// Title of the position
+	// in: string
+	// required: true
+	// example: Senior Software Engineer
+	Title string `json:"title" validate:"required,notblank,max=56"`
+	// Job family the position belongs to
+	// in: string
+	// example: Engineering
+	JobFamily string `json:"job_family" validate:"max=56"`
+	// Level of the position within the job family
+	// in: integer
+	// example: 3
+	Level int `json:"level" validate:"min=0,max=255"`
+}
+
+// UpdatePosition defines what information may be provided to
+// modify an existing Position. All fields are optional
+// so clients can send just the fields they want changed.
+//
+//swagger:model UpdatePosition
+type UpdatePosition struct {
+	// Title of the position
+	// in: string
+	// example: Staff Software Engineer
+	Title *string `json:"title" validate:"omitempty,notblank,max=56"`
+	// Job family the position belongs to
+	// in: string
+	// example: Engineering
+	JobFamily *string `json:"job_family" validate:"omitempty,max=56"`
+	// Level of the position within the job family
+	// in: integer
+	// example: 4
+	Level *int `json:"level" validate:"omitempty,min=0,max=255"`
+}
+
+// =============================================================================
+
+func toPosition(dbRS db.Position) Position {
+	return Position{
+		ID:        dbRS.ID,
+		Title:     dbRS.Title,
+		JobFamily: dbRS.JobFamily,
+		Level:     dbRS.Level,
+		CreatedOn: dbRS.CreatedOn,
+		UpdatedOn: dbRS.UpdatedOn,
+		DeletedOn: dbRS.DeletedOn,
+	}
+}
+
+func toPositionSlice(dbRSs []db.Position) []Position {
+	rs := make([]Position, len(dbRSs))
+	for i, dbRS := range dbRSs {
+		rs[i] = toPosition(dbRS)
+	}
+	return rs
+}
+
+//------------------------------------------------------------------------
+// Fake data generators
+//------------------------------------------------------------------------
+
+// GenerateFakeData return an array for NewPositions
+func (np NewPosition) GenerateFakeData(num int) []NewPosition {
+	var data []NewPosition
+	for i := 0; i < num; i++ {
+		data = append(data, np.fakeData(i+1))
+	}
+	return data
+}
+
+// fakeData creates the fake record. Names are unique so the records can be
+// inserted repeatedly.
+func (np NewPosition) fakeData(counter int) NewPosition {
+	return NewPosition{
+		Title:     fmt.Sprintf("Position %d-%d", time.Now().UnixNano()%1000000000, counter),
+		JobFamily: "Engineering",
+		Level:     counter,
+	}
+}
+
+// Seed runs create methods from an array of new values
+func (c Core) Seed(ctx context.Context, data []NewPosition) error {
+	now := time.Now().UTC()
+	for _, np := range data {
+		if _, err := c.Create(ctx, np, now); err != nil {
+			return fmt.Errorf("error seeding position: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package position for position handler functions
+package position
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/position/db"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("position not found")
+	ErrInvalidID    = errors.New("ID is not in its proper form")
+	ErrHasEmployees = errors.New("position still has active employees")
+)
+
+// Core manages the set of APIs for position access
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for position api access.
+func NewCore(log *slog.Logger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// -----------------------------------------------------------------------
+// CRUD Methods
+// -----------------------------------------------------------------------
+
+// Create inserts a new position into the database
+func (c Core) Create(ctx context.Context, np NewPosition, now time.Time) (Position, error) {
+	if err := validate.Check(np); err != nil {
+		return Position{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbRS := db.Position{
+		Title:     strings.TrimSpace(np.Title),
+		JobFamily: strings.TrimSpace(np.JobFamily),
+		Level:     np.Level,
+		CreatedOn: now,
+		UpdatedOn: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		res, err := c.store.Tran(tx).Create(ctx, dbRS)
+		if err != nil {
+			return err
+		}
+		dbRS.ID = fmt.Sprintf("%d", res.LastInsertID)
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return Position{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toPosition(dbRS), nil
+}
+
+// Update replaces a position document in the database.
+func (c Core) Update(ctx context.Context, id string, up UpdatePosition, now time.Time) error {
+	if err := validate.Check(up); err != nil {
+		return err
+	}
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	// The record is locked from the read to the write so concurrent
+	// updates cannot overwrite each other.
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbRS, err := store.QueryByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("updating position id[%s]: %w", id, err)
+		}
+
+		isEmpty := true
+		if up.Title != nil {
+			dbRS.Title = strings.TrimSpace(*up.Title)
+			isEmpty = false
+		}
+		if up.JobFamily != nil {
+			dbRS.JobFamily = strings.TrimSpace(*up.JobFamily)
+			isEmpty = false
+		}
+		if up.Level != nil {
+			dbRS.Level = *up.Level
+			isEmpty = false
+		}
+		// No changes were made - don't touch the DB
+		if isEmpty {
+			return nil
+		}
+		dbRS.UpdatedOn = now
+
+		if _, err := store.Update(ctx, dbRS); err != nil {
+			return fmt.Errorf("update id[%s]: %w", id, err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, nil, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a position from the database. Positions that still
+// have active employees cannot be deleted. The transaction is serializable
+// so no employee can be given the position between the count and the
+// delete.
+func (c Core) Delete(ctx context.Context, id string, now time.Time) error {
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if _, err := store.QueryByIDForUpdate(ctx, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("deleting position id[%s]: %w", id, err)
+		}
+
+		total, err := store.CountActiveEmployees(ctx, id)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return ErrHasEmployees
+		}
+
+		if _, err := store.Delete(ctx, id, now); err != nil {
+			return fmt.Errorf("delete id[%s]: %w", id, err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing records from the database
+func (c Core) Query(ctx context.Context, pagi database.Pagination) ([]Position, error) {
+	res, err := c.store.Query(ctx, pagi)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPositionSlice(res), nil
+}
+
+// QueryByID retrieves a single records from the database by id
+func (c Core) QueryByID(ctx context.Context, id string) (Position, error) {
+	if err := validate.CheckID(id); err != nil {
+		return Position{}, ErrInvalidID
+	}
+
+	res, err := c.store.QueryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Position{}, ErrNotFound
+		}
+		return Position{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toPosition(res), nil
+}
+
+// UnDelete restore a deleted position from the database.
+func (c Core) UnDelete(ctx context.Context, id string, now time.Time) error {
+	if err := validate.CheckID(id); err != nil {
+		return ErrInvalidID
+	}
+
+	_, err := c.store.UnDelete(ctx, id, now)
+	if err != nil {
+		return fmt.Errorf("position id[%s]: %w", id, err)
+	}
+
+	return nil
+}
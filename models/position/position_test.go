@@ -0,0 +1,231 @@
+package position_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pansachin/employee-service/models/employee"
+	"github.com/pansachin/employee-service/models/position"
+	"github.com/pansachin/employee-service/pkg/database"
+	"github.com/pansachin/employee-service/pkg/database/dbtest"
+	"github.com/pansachin/employee-service/pkg/validate"
+)
+
+type TestSuite struct {
+	db       *sqlx.DB
+	log      *slog.Logger
+	teardown func()
+
+	tableNames []string
+	ctx        context.Context
+}
+
+var ts TestSuite
+
+// TableNames are copied into test_db, employees are needed to check that
+// positions are resolved by their normalized title.
+var TableNames = []string{"position", "department", "employee", "employee_audit"}
+
+func TestMain(m *testing.M) {
+	success := m.Run()
+	ts.teardown()
+	os.Exit(success)
+}
+
+func setupTestDB() error {
+	for _, tableName := range ts.tableNames {
+		// Make sure we have sufficient permission for the db user
+		q := fmt.Sprintf("create table if not exists test_db.%s like %s.%s", tableName, dbtest.UnitDbConfig.Name, tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("creating test_db.%s test table: %v", tableName, err)
+		}
+
+		q = fmt.Sprintf("truncate table test_db.%s", tableName)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("truncating test_db.%s test table: %v", tableName, err)
+		}
+
+		q = fmt.Sprintf(`
+			insert into test_db.%s
+			select * from %s.%s
+			order by created_on desc limit 100`,
+			tableName,
+			dbtest.UnitDbConfig.Name,
+			tableName,
+		)
+		if _, err := ts.db.ExecContext(ts.ctx, q); err != nil {
+			return fmt.Errorf("error copying data to test_db.%s test table: %v", tableName, err)
+		}
+	}
+
+	return nil
+}
+
+func registerTestSuite(t *testing.T) {
+	if ts.db == nil {
+		log, db, teardown := dbtest.NewUnit(t)
+		ctx := context.Background()
+		ts = TestSuite{
+			db:         db,
+			log:        log,
+			teardown:   teardown,
+			ctx:        ctx,
+			tableNames: TableNames,
+		}
+
+		t.Logf("Create test database tables %v", ts.tableNames)
+		if err := setupTestDB(); err != nil {
+			t.Fatalf("Failed to create test tables: %v", err)
+		}
+	}
+}
+
+func Test_PositionTitle(t *testing.T) {
+	registerTestSuite(t)
+
+	// Use throughout the test
+	pc := position.NewCore(ts.log, ts.db)
+	ec := employee.NewCore(ts.log, ts.db)
+
+	// hard coded for easy testing
+	now := time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	var nd position.NewPosition
+	data := nd.GenerateFakeData(2)
+
+	// stored keeps the positions created by the cases for the employee checks.
+	var stored []position.Position
+
+	t.Log("Given the need to keep Position titles normalized")
+	{
+		testID := 1
+		t.Logf("\tTest %d:\tWhen creating and updating Positions.", testID)
+		{
+			// The cases run in order: the duplicate checks rely on the
+			// titles stored by the cases before them.
+			cases := []struct {
+				name   string
+				create string
+				update *string
+				want   string
+				check  func(error) bool
+			}{
+				{
+					name:   "surrounding spaces are trimmed on create",
+					create: "  " + data[0].Title + "\t",
+					want:   data[0].Title,
+				},
+				{
+					name:   "surrounding spaces are trimmed on update",
+					create: data[1].Title + " draft",
+					update: dbtest.StringPointer(" " + data[1].Title + "  "),
+					want:   data[1].Title,
+				},
+				{
+					name:   "a title differing only in case is a duplicate",
+					create: strings.ToUpper(data[0].Title),
+					check:  isConflict,
+				},
+				{
+					name:   "a padded title is a duplicate",
+					create: "   " + data[0].Title,
+					check:  isConflict,
+				},
+				{
+					name:   "a blank title is refused",
+					create: "   ",
+					check:  validate.IsFieldErrors,
+				},
+				{
+					name:   "a title over 56 characters is refused",
+					create: strings.Repeat("x", 57),
+					check:  validate.IsFieldErrors,
+				},
+			}
+
+			for _, tc := range cases {
+				np := data[0]
+				np.Title = tc.create
+
+				pos, err := pc.Create(ts.ctx, np, now)
+				if tc.check != nil {
+					if !tc.check(err) {
+						t.Fatalf("\t%s\tTest %d:\t%s : %v", dbtest.Failed, testID, tc.name, err)
+					}
+					t.Logf("\t%s\tTest %d:\t%s", dbtest.Success, testID, tc.name)
+					testID++
+					continue
+				}
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\t%s : should be able to create Position : %s", dbtest.Failed, testID, tc.name, err)
+				}
+
+				if tc.update != nil {
+					if err := pc.Update(ts.ctx, pos.ID, position.UpdatePosition{Title: tc.update}, now); err != nil {
+						t.Fatalf("\t%s\tTest %d:\t%s : should be able to update Position : %s", dbtest.Failed, testID, tc.name, err)
+					}
+				}
+
+				fetched, err := pc.QueryByID(ts.ctx, pos.ID)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\t%s : should be able to retrieve Position by ID : %s", dbtest.Failed, testID, tc.name, err)
+				}
+				if fetched.Title != tc.want {
+					t.Fatalf("\t%s\tTest %d:\t%s : expected title %q, got %q", dbtest.Failed, testID, tc.name, tc.want, fetched.Title)
+				}
+				stored = append(stored, fetched)
+				t.Logf("\t%s\tTest %d:\t%s", dbtest.Success, testID, tc.name)
+				testID++
+			}
+		}
+
+		t.Logf("\tTest %d:\tWhen assigning Positions to Employees by title.", testID)
+		{
+			pos := stored[0]
+
+			cases := []struct {
+				name  string
+				title string
+			}{
+				{
+					name:  "the catalog title",
+					title: pos.Title,
+				},
+				{
+					name:  "the title in another case",
+					title: strings.ToLower(pos.Title),
+				},
+				{
+					name:  "the title with surrounding spaces",
+					title: "  " + strings.ToUpper(pos.Title) + " ",
+				},
+			}
+
+			for _, tc := range cases {
+				emp, err := ec.Create(ts.ctx, employee.NewEmployee{Name: "Sachin Prasad", Position: tc.title}, now)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to assign a Position by %s : %s", dbtest.Failed, testID, tc.name, err)
+				}
+				if emp.PositionID == nil || *emp.PositionID != pos.ID || emp.Position != pos.Title {
+					t.Fatalf("\t%s\tTest %d:\tShould resolve %s to Position %s %q, got %v %q", dbtest.Failed, testID, tc.name, pos.ID, pos.Title, emp.PositionID, emp.Position)
+				}
+				t.Logf("\t%s\tTest %d:\tShould be able to assign a Position by %s", dbtest.Success, testID, tc.name)
+				testID++
+			}
+		}
+	}
+}
+
+// isConflict reports whether err is the 409 returned for a duplicated title.
+func isConflict(err error) bool {
+	dbErr := database.GetError(err)
+	return dbErr != nil && dbErr.Status == http.StatusConflict
+}
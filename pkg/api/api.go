@@ -3,11 +3,18 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -19,32 +26,50 @@ type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) e
 // data/logic on this Api struct
 type API struct {
 	mux      *http.ServeMux
+	log      *slog.Logger
 	shutdown chan os.Signal
 	mw       []Middleware
+	problem  bool
 }
 
 // NewAPI creates an Api value that handle a set of routes for the application
-func NewAPI(shutdown chan os.Signal, mw ...Middleware) *API {
+func NewAPI(log *slog.Logger, shutdown chan os.Signal, mw ...Middleware) *API {
 
 	mux := http.NewServeMux()
 
 	return &API{
 		mux:      mux,
+		log:      log,
 		shutdown: shutdown,
 		mw:       mw,
 	}
 }
 
 // SignalShutdown is used to gracefully shut down the app when an integrity
-// issue is identified
+// issue is identified. The send never blocks: if no shutdown channel was
+// provided, or a shutdown is already pending, the request is only logged.
 func (a *API) SignalShutdown() {
-	a.shutdown <- syscall.SIGTERM
+	select {
+	case a.shutdown <- syscall.SIGTERM:
+	default:
+		a.log.Warn("shutdown requested", "status", "signal not delivered, shutdown channel unavailable or already signaled")
+	}
+}
+
+// SetProblemDefault sends errors as RFC 7807 problem details to the clients
+// accepting JSON, not only to those asking for application/problem+json.
+func (a *API) SetProblemDefault(on bool) {
+	a.problem = on
 }
 
 // Handle sets a handler function for a given HTTP method and path pair
 // to the application server mux
 func (a *API) Handle(method string, path string, handler Handler, mw ...Middleware) {
 
+	// Refuse the requests whose response cannot be sent in an accepted
+	// media type, once the caller passed the route middleware.
+	handler = acceptable(handler)
+
 	// First wrap handler specific middleware around this handler
 	handler = wrapMiddleware(mw, handler)
 
@@ -59,25 +84,48 @@ func (a *API) Handle(method string, path string, handler Handler, mw ...Middlewa
 		// use it as a separate parameter.
 		ctx := r.Context()
 
-		// Capture the parent request span from the context.
-		// Once we properly setup otel / tracing
-		span := trace.SpanFromContext(ctx)
+		// Continue the trace started by the caller, if any, from the
+		// traceparent and tracestate headers.
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+
+		// Start the server span for this route.
+		ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, method+" "+path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.HTTPRoute(path),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
 
 		// Set the context with the required values to
 		// process the request.
+		accept := r.Header.Get("Accept")
+		mediaTypes := Acceptable(accept)
 		v := ContextValues{
-			TracerUID: span.SpanContext().TraceID().String(),
-			Now:       time.Now(),
+			TracerUID:   span.SpanContext().TraceID().String(),
+			Now:         time.Now(),
+			MediaTypes:  mediaTypes,
+			Problem:     acceptsProblem(accept) || (a.problem && (len(mediaTypes) == 0 || mediaTypes[0] == MediaTypeJSON)),
+			RequestPath: r.URL.Path,
 		}
 		ctx = context.WithValue(ctx, key, &v)
 
 		// Register this path and tracer uid for metrics later on
 		_ = SetPath(ctx, path)
 
-		// Call the wrapped handler functions.
+		// Call the wrapped handler functions. The middleware chain is
+		// expected to handle every error, so anything reaching this point
+		// escaped it and must be classified here.
 		if err := handler(ctx, w, r); err != nil {
-			a.SignalShutdown()
-			return
+			span.RecordError(err)
+			a.handleError(ctx, w, err)
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(v.StatusCode))
+		if v.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(v.StatusCode))
 		}
 
 	})
@@ -85,6 +133,67 @@ func (a *API) Handle(method string, path string, handler Handler, mw ...Middlewa
 	a.mux.HandleFunc(method+" "+path, h)
 }
 
+// acceptable answers 406 to requests accepting none of the media types
+// responses can be encoded in. Requests changing data are also refused
+// before the handler runs when the accepted types only carry some bodies,
+// e.g. CSV lists, otherwise the 406 would come after the change was made.
+func acceptable(handler Handler) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		v, err := GetContextValues(ctx)
+		if err != nil {
+			return handler(ctx, w, r)
+		}
+
+		if len(v.MediaTypes) == 0 {
+			err := fmt.Errorf("none of the accepted media types is supported, use one of %s", strings.Join(MediaTypes(), ", "))
+			return NewRequestError(err, http.StatusNotAcceptable)
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !encodesAny(v.MediaTypes) {
+			err := fmt.Errorf("the response to a %s cannot be sent as %s", r.Method, strings.Join(v.MediaTypes, ", "))
+			return NewRequestError(err, http.StatusNotAcceptable)
+		}
+
+		return handler(ctx, w, r)
+	}
+}
+
+// handleError deals with errors that escaped the middleware chain. Only
+// shutdown errors stop the service, every other error is logged, counted
+// and turned into a 500 response if nothing was written to the client yet.
+func (a *API) handleError(ctx context.Context, w http.ResponseWriter, err error) {
+	v, _ := GetContextValues(ctx)
+
+	// Set the error count for the request middleware
+	_ = SetIsError(ctx)
+
+	if IsShutdown(err) {
+		a.log.Error("SHUTDOWN ERROR", "tracer_uid", v.TracerUID, "path", v.Path, slog.Any("ERROR", err))
+		a.respondInternalError(ctx, w, v)
+		a.SignalShutdown()
+		return
+	}
+
+	a.log.Error("UNHANDLED ERROR", "tracer_uid", v.TracerUID, "path", v.Path, slog.Any("ERROR", err))
+	a.respondInternalError(ctx, w, v)
+}
+
+// respondInternalError sends a generic 500 back to the client unless a
+// response has already been written for this request.
+func (a *API) respondInternalError(ctx context.Context, w http.ResponseWriter, v *ContextValues) {
+	if v.StatusCode != 0 {
+		return
+	}
+
+	er := ErrorResponse{
+		Error: http.StatusText(http.StatusInternalServerError),
+		Code:  StatusCode(http.StatusInternalServerError),
+	}
+	if err := Respond(ctx, w, er, http.StatusInternalServerError); err != nil {
+		a.log.Error("UNHANDLED ERROR", "tracer_uid", v.TracerUID, "status", "unable to respond", slog.Any("ERROR", err))
+	}
+}
+
 // ServeHTTP implements the http.Handler interface. It's the entry point for
 // all http traffic.
 func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
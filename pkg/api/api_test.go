@@ -0,0 +1,555 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/api/middleware"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+// newTestAPI builds an API with the same middleware chain used by the
+// service and a set of handlers producing the different error classes.
+func newTestAPI(shutdown chan os.Signal) *api.API {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	a := api.NewAPI(
+		log,
+		shutdown,
+		middleware.Logger(log),
+		middleware.Errors(log),
+		middleware.Panics(),
+	)
+
+	a.Handle(http.MethodGet, "/ok", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.Respond(ctx, w, []string{"ok"}, http.StatusOK)
+	})
+	a.Handle(http.MethodPost, "/bad-request", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var v struct {
+			Name string `json:"name"`
+		}
+		return api.Decode(r, &v)
+	})
+	a.Handle(http.MethodGet, "/panic", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		panic("something unexpected")
+	})
+	a.Handle(http.MethodGet, "/error", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("unexpected failure")
+	})
+	a.Handle(http.MethodGet, "/shutdown", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.NewShutdownError("integrity issue")
+	})
+
+	return a
+}
+
+// newBareAPI builds an API without any middleware so errors returned by the
+// handlers reach the API itself.
+func newBareAPI(shutdown chan os.Signal) *api.API {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	a := api.NewAPI(log, shutdown)
+	a.Handle(http.MethodGet, "/escaped", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("escaped the middleware chain")
+	})
+	a.Handle(http.MethodGet, "/escaped-shutdown", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.NewShutdownError("integrity issue")
+	})
+
+	return a
+}
+
+func Test_HandleErrors(t *testing.T) {
+	cases := []struct {
+		name         string
+		bare         bool
+		method       string
+		path         string
+		body         string
+		wantStatus   int
+		wantShutdown bool
+		wantError    string
+	}{
+		{
+			name:       "success",
+			method:     http.MethodGet,
+			path:       "/ok",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bad request",
+			method:     http.MethodPost,
+			path:       "/bad-request",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "json missing opening or closing brackets",
+		},
+		{
+			name:       "panic",
+			method:     http.MethodGet,
+			path:       "/panic",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:       "unexpected error",
+			method:     http.MethodGet,
+			path:       "/error",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:       "error escaping the middleware chain",
+			bare:       true,
+			method:     http.MethodGet,
+			path:       "/escaped",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  http.StatusText(http.StatusInternalServerError),
+		},
+		{
+			name:         "shutdown error",
+			method:       http.MethodGet,
+			path:         "/shutdown",
+			wantStatus:   http.StatusInternalServerError,
+			wantShutdown: true,
+		},
+		{
+			name:         "shutdown error escaping the middleware chain",
+			bare:         true,
+			method:       http.MethodGet,
+			path:         "/escaped-shutdown",
+			wantStatus:   http.StatusInternalServerError,
+			wantShutdown: true,
+		},
+	}
+
+	t.Log("Given the need to classify errors returned by handlers")
+	for testID, tc := range cases {
+		shutdown := make(chan os.Signal, 1)
+		a := newTestAPI(shutdown)
+		if tc.bare {
+			a = newBareAPI(shutdown)
+		}
+
+		r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+
+		if w.Code != tc.wantStatus {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould respond with %d, got %d", Failed, testID, tc.name, tc.wantStatus, w.Code)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould respond with %d", Success, testID, tc.name, tc.wantStatus)
+
+		if tc.wantError != "" {
+			var resp struct {
+				Success bool              `json:"success"`
+				Errors  api.ErrorResponse `json:"errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould decode the response : %s", Failed, testID, tc.name, err)
+			}
+			if resp.Success || resp.Errors.Error != tc.wantError {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould get error %q, got %q", Failed, testID, tc.name, tc.wantError, resp.Errors.Error)
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould get error %q", Success, testID, tc.name, tc.wantError)
+		}
+
+		select {
+		case <-shutdown:
+			if !tc.wantShutdown {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould NOT signal a shutdown", Failed, testID, tc.name)
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould signal a shutdown", Success, testID, tc.name)
+		default:
+			if tc.wantShutdown {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould signal a shutdown", Failed, testID, tc.name)
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould NOT signal a shutdown", Success, testID, tc.name)
+		}
+	}
+}
+
+func Test_SignalShutdownWithoutChannel(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil, middleware.Errors(log))
+	a.Handle(http.MethodGet, "/shutdown", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.NewShutdownError("integrity issue")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/shutdown", nil))
+	}()
+
+	select {
+	case <-done:
+		t.Logf("\t%s\tTest 1:\tShould not block when no shutdown channel is set", Success)
+	case <-time.After(time.Second):
+		t.Fatalf("\t%s\tTest 1:\tShould not block when no shutdown channel is set", Failed)
+	}
+}
+
+func Test_TraceparentPropagation(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil)
+
+	var got string
+	a.Handle(http.MethodGet, "/trace", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		got = api.GetTracerUID(ctx)
+		return api.Respond(ctx, w, nil, http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodGet, "/trace", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	a.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got != traceID {
+		t.Fatalf("\t%s\tTest 1:\tShould continue the caller's trace %s, got %s", Failed, traceID, got)
+	}
+	t.Logf("\t%s\tTest 1:\tShould continue the caller's trace", Success)
+
+	r = httptest.NewRequest(http.MethodGet, "/trace", nil)
+	a.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got == "" || got == (trace.TraceID{}).String() || got == traceID {
+		t.Fatalf("\t%s\tTest 2:\tShould start a new trace, got %s", Failed, got)
+	}
+	t.Logf("\t%s\tTest 2:\tShould start a new trace", Success)
+}
+
+func Test_RespondPage(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil)
+	a.Handle(http.MethodGet, "/list", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.RespondPage(ctx, w, r, api.Page{Data: []string{"a"}, NextCursor: "n.x", PrevCursor: "p.x"}, http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/list?page=3&name=jo", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	want := `</list?cursor=n.x&name=jo>; rel="next", </list?cursor=p.x&name=jo>; rel="prev"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Fatalf("\t%s\tTest 1:\tShould link to the next and previous pages, got %s", Failed, got)
+	}
+	t.Logf("\t%s\tTest 1:\tShould link to the next and previous pages", Success)
+
+	var resp struct {
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.NextCursor != "n.x" || resp.PrevCursor != "p.x" {
+		t.Fatalf("\t%s\tTest 2:\tShould return the cursors in the body : %v %v", Failed, resp, err)
+	}
+	t.Logf("\t%s\tTest 2:\tShould return the cursors in the body", Success)
+}
+
+func Test_IfNoneMatch(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "no header", header: "", want: false},
+		{name: "same tag", header: `"3"`, want: true},
+		{name: "weak tag", header: `W/"3"`, want: true},
+		{name: "list", header: `"1", "3"`, want: true},
+		{name: "any", header: "*", want: true},
+		{name: "other tag", header: `"2"`, want: false},
+	}
+
+	t.Log("Given the need to answer conditional requests")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/employee/1", nil)
+		if tc.header != "" {
+			r.Header.Set("If-None-Match", tc.header)
+		}
+
+		if got := api.IfNoneMatch(r, api.ETag("3")); got != tc.want {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould match %t, got %t", Failed, testID, tc.name, tc.want, got)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould match %t", Success, testID, tc.name, tc.want)
+	}
+}
+
+func Test_RespondNotModified(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil)
+	a.Handle(http.MethodGet, "/cached", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.Respond(ctx, w, nil, http.StatusNotModified)
+	})
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cached", nil))
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("\t%s\tTest 1:\tShould respond 304 without a body, got %d %q", Failed, w.Code, w.Body.String())
+	}
+	t.Logf("\t%s\tTest 1:\tShould respond 304 without a body", Success)
+}
+
+func Test_Negotiate(t *testing.T) {
+	cases := []struct {
+		name   string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{name: "no header", accept: "", want: api.MediaTypeJSON, ok: true},
+		{name: "any", accept: "*/*", want: api.MediaTypeJSON, ok: true},
+		{name: "xml", accept: "application/xml", want: api.MediaTypeXML, ok: true},
+		{name: "quality", accept: "application/json;q=0.5, text/csv", want: api.MediaTypeCSV, ok: true},
+		{name: "type range", accept: "text/*", want: "text/xml", ok: true},
+		{name: "problem details", accept: "application/problem+json", want: api.MediaTypeJSON, ok: true},
+		{name: "refused", accept: "application/msgpack;q=0, image/png", want: api.MediaTypeJSON, ok: false},
+	}
+
+	t.Log("Given the need to negotiate the media type of responses")
+	for testID, tc := range cases {
+		got, ok := api.Negotiate(tc.accept)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould pick %s %t, got %s %t", Failed, testID, tc.name, tc.want, tc.ok, got, ok)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould pick %s %t", Success, testID, tc.name, tc.want, tc.ok)
+	}
+}
+
+func Test_RespondMediaTypes(t *testing.T) {
+	type row struct {
+		ID      string  `json:"id"`
+		Name    string  `json:"name"`
+		Manager *string `json:"manager_id"`
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil, middleware.Errors(log))
+	a.Handle(http.MethodGet, "/list", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.Respond(ctx, w, []row{{ID: "1", Name: "Jo, Jr."}, {ID: "2", Name: "Al"}}, http.StatusOK)
+	})
+	a.Handle(http.MethodGet, "/one", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return api.Respond(ctx, w, row{ID: "1", Name: "Jo"}, http.StatusOK)
+	})
+
+	// changes counts the calls of the handler changing data, a refused
+	// request must not reach it.
+	var changes int
+	a.Handle(http.MethodPatch, "/one", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		changes++
+		return api.Respond(ctx, w, nil, http.StatusOK)
+	})
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{name: "json", path: "/list", accept: "application/json", wantStatus: http.StatusOK, wantType: api.MediaTypeJSON, wantBody: `"name":"Jo, Jr."`},
+		{name: "xml", path: "/one", accept: "application/xml", wantStatus: http.StatusOK, wantType: api.MediaTypeXML, wantBody: "<data><id>1</id><name>Jo</name></data>"},
+		{name: "csv list", path: "/list", accept: "text/csv", wantStatus: http.StatusOK, wantType: api.MediaTypeCSV, wantBody: "id,name,manager_id\n1,\"Jo, Jr.\",\n2,Al,\n"},
+		{name: "csv object", path: "/one", accept: "text/csv", wantStatus: http.StatusNotAcceptable, wantType: api.MediaTypeJSON, wantBody: "cannot be sent as text/csv"},
+		{name: "csv object with fallback", path: "/one", accept: "text/csv, application/xml;q=0.5", wantStatus: http.StatusOK, wantType: api.MediaTypeXML, wantBody: "<name>Jo</name>"},
+		{name: "not acceptable", path: "/one", accept: "image/png", wantStatus: http.StatusNotAcceptable, wantType: api.MediaTypeJSON, wantBody: "none of the accepted media types"},
+		{name: "csv change", method: http.MethodPatch, path: "/one", accept: "text/csv", wantStatus: http.StatusNotAcceptable, wantType: api.MediaTypeJSON, wantBody: "response to a PATCH cannot be sent as text/csv"},
+		{name: "csv change with fallback", method: http.MethodPatch, path: "/one", accept: "text/csv, application/json;q=0.5", wantStatus: http.StatusOK, wantType: api.MediaTypeJSON, wantBody: `"success":true`},
+	}
+
+	t.Log("Given the need to respond in the media type accepted by the client")
+	for testID, tc := range cases {
+		method := tc.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		r := httptest.NewRequest(method, tc.path, nil)
+		r.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+
+		if w.Code != tc.wantStatus || w.Header().Get("Content-Type") != tc.wantType || !strings.Contains(w.Body.String(), tc.wantBody) {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould respond %d as %s, got %d %s %q", Failed, testID, tc.name, tc.wantStatus, tc.wantType, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould respond %d as %s", Success, testID, tc.name, tc.wantStatus, tc.wantType)
+	}
+	if changes != 1 {
+		t.Fatalf("\t%s\tTest %d [csv change]:\tShould only run the change accepting JSON, ran %d", Failed, len(cases), changes)
+	}
+	t.Logf("\t%s\tTest %d [csv change]:\tShould only run the change accepting JSON", Success, len(cases))
+
+	r := httptest.NewRequest(http.MethodGet, "/one", nil)
+	r.Header.Set("Accept", api.MediaTypeMsgpack)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	var resp struct {
+		Success bool `msgpack:"success"`
+		Data    struct {
+			ID   string `msgpack:"id"`
+			Name string `msgpack:"name"`
+		} `msgpack:"data"`
+	}
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &resp); err != nil || !resp.Success || resp.Data.Name != "Jo" {
+		t.Fatalf("\t%s\tTest %d [msgpack]:\tShould respond in MessagePack : %v %v", Failed, len(cases)+1, resp, err)
+	}
+	t.Logf("\t%s\tTest %d [msgpack]:\tShould respond in MessagePack", Success, len(cases)+1)
+}
+
+func Test_DecodeMediaTypes(t *testing.T) {
+	type payload struct {
+		Name   string   `json:"name"`
+		Age    int      `json:"age"`
+		Tags   []string `json:"tags"`
+		Parent *string  `json:"parent_id"`
+	}
+
+	packed, err := msgpack.Marshal(map[string]interface{}{"name": "Jo", "age": 42, "tags": []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to encode MessagePack : %s", Failed, err)
+	}
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"name":"Jo","age":42,"tags":["a","b"]}`},
+		{name: "no content type", body: `{"name":"Jo","age":42,"tags":["a","b"]}`},
+		{name: "xml", contentType: "application/xml", body: `<employee><name>Jo</name><age>42</age><tags><item>a</item><item>b</item></tags><parent_id nil="true"/></employee>`},
+		{name: "msgpack", contentType: api.MediaTypeMsgpack, body: string(packed)},
+		{name: "xml wrong type", contentType: "text/xml", body: `<employee><name>Jo</name><age>old</age></employee>`, wantStatus: http.StatusBadRequest},
+		{name: "xml unknown field", contentType: "text/xml", body: `<employee><nick>Jo</nick></employee>`, wantStatus: http.StatusBadRequest},
+		{name: "unsupported", contentType: "text/plain", body: "Jo", wantStatus: http.StatusUnsupportedMediaType},
+	}
+
+	t.Log("Given the need to read request bodies in several media types")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/employee", strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+
+		var p payload
+		err := api.Decode(r, &p)
+		if tc.wantStatus != 0 {
+			if re := api.GetRequestError(err); re == nil || re.Status != tc.wantStatus {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould fail with %d : %v", Failed, testID, tc.name, tc.wantStatus, err)
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould fail with %d", Success, testID, tc.name, tc.wantStatus)
+			continue
+		}
+
+		if err != nil || p.Name != "Jo" || p.Age != 42 || len(p.Tags) != 2 || p.Parent != nil {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould decode the body : %+v %v", Failed, testID, tc.name, p, err)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould decode the body", Success, testID, tc.name)
+	}
+}
+
+func Test_ProblemDetails(t *testing.T) {
+	errMissing := errors.New("widget not found")
+	api.RegisterErrorCode(errMissing, "widget_not_found")
+
+	newAPI := func(problemDefault bool) *api.API {
+		log := slog.New(slog.NewTextHandler(io.Discard, nil))
+		a := api.NewAPI(log, nil, middleware.Errors(log))
+		a.SetProblemDefault(problemDefault)
+		a.Handle(http.MethodGet, "/widget/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return api.NewRequestError(fmt.Errorf("query: %w", errMissing), http.StatusNotFound)
+		})
+		a.Handle(http.MethodGet, "/widget", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return api.Respond(ctx, w, []string{"widget"}, http.StatusOK)
+		})
+		return a
+	}
+
+	cases := []struct {
+		name           string
+		problemDefault bool
+		accept         string
+		wantType       string
+	}{
+		{name: "envelope", accept: "application/json", wantType: api.MediaTypeJSON},
+		{name: "opt in", accept: "application/json, application/problem+json", wantType: api.MediaTypeProblemJSON},
+		{name: "only problems", accept: "application/problem+json", wantType: api.MediaTypeProblemJSON},
+		{name: "opt out", accept: "application/json, application/problem+json;q=0", wantType: api.MediaTypeJSON},
+		{name: "default", problemDefault: true, wantType: api.MediaTypeProblemJSON},
+		{name: "default with xml", problemDefault: true, accept: "application/xml", wantType: api.MediaTypeXML},
+	}
+
+	t.Log("Given the need to answer errors as problem details")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/widget/7", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		newAPI(tc.problemDefault).ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != tc.wantType || !strings.Contains(w.Body.String(), "widget_not_found") {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould answer as %s with the code, got %d %s %q", Failed, testID, tc.name, tc.wantType, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+		if tc.wantType != api.MediaTypeProblemJSON {
+			t.Logf("\t%s\tTest %d [%s]:\tShould answer as %s with the code", Success, testID, tc.name, tc.wantType)
+			continue
+		}
+
+		var p api.Problem
+		if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould decode the problem : %s", Failed, testID, tc.name, err)
+		}
+		want := api.Problem{
+			Type:     "urn:employee-service:problem:widget_not_found",
+			Title:    "Not Found",
+			Status:   http.StatusNotFound,
+			Detail:   "query: widget not found",
+			Instance: "/widget/7",
+			Code:     "widget_not_found",
+		}
+		if diff := cmp.Diff(want, p); diff != "" {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould describe the problem, diff:\n%s", Failed, testID, tc.name, diff)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould answer as %s with the code", Success, testID, tc.name, tc.wantType)
+	}
+
+	testID := len(cases)
+	r := httptest.NewRequest(http.MethodGet, "/widget", nil)
+	r.Header.Set("Accept", api.MediaTypeProblemJSON)
+	w := httptest.NewRecorder()
+	newAPI(false).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != api.MediaTypeJSON || !strings.Contains(w.Body.String(), `"widget"`) {
+		t.Fatalf("\t%s\tTest %d [success]:\tShould answer success as JSON to clients accepting problem details, got %d %s %q", Failed, testID, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	t.Logf("\t%s\tTest %d [success]:\tShould answer success as JSON to clients accepting problem details", Success, testID)
+}
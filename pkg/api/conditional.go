@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ETag quotes the value into a strong entity tag.
+func ETag(value string) string {
+	return `"` + value + `"`
+}
+
+// ETags splits a If-Match or If-None-Match header into its entity tags.
+// Weak tags keep their W/ prefix.
+func ETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// IfNoneMatch reports whether the If-None-Match header of the request
+// matches the entity tag, in which case a GET can be answered with 304.
+// The comparison is weak as RFC 9110 requires for this header.
+func IfNoneMatch(r *http.Request, etag string) bool {
+	for _, tag := range ETags(r.Header.Get("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -12,6 +12,16 @@ type ctxKey int
 // key is how request values are stored/retrieved.
 const key ctxKey = 1
 
+// Principal represents the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller, e.g. the JWT subject or API key name.
+	Subject string
+	// Roles granted to the caller.
+	Roles []string
+	// Method used to authenticate the caller, e.g. jwt or apikey.
+	Method string
+}
+
 // ContextValues represent state for each request.
 type ContextValues struct {
 	TracerUID  string
@@ -20,6 +30,20 @@ type ContextValues struct {
 	IsError    bool
 	IsPanic    bool
 	Path       string
+	Principal  Principal
+	// MediaTypes the response can be sent in, negotiated from the Accept
+	// header, the preferred first.
+	MediaTypes []string
+	// Problem sends errors as RFC 7807 problem details.
+	Problem bool
+	// RequestPath is the path of the request, the instance of problems.
+	RequestPath string
+}
+
+// WithValues returns a copy of ctx carrying the values, for work started
+// outside of a request such as scheduled jobs.
+func WithValues(ctx context.Context, v *ContextValues) context.Context {
+	return context.WithValue(ctx, key, v)
 }
 
 // GetContextValues returns the values from the context.
@@ -90,3 +114,23 @@ func SetPath(ctx context.Context, path string) error {
 	v.Path = path
 	return nil
 }
+
+// SetPrincipal sets the authenticated caller back into the context.
+func SetPrincipal(ctx context.Context, p Principal) error {
+	v, ok := ctx.Value(key).(*ContextValues)
+	if !ok {
+		return errors.New("api value missing from context")
+	}
+	v.Principal = p
+	return nil
+}
+
+// GetPrincipal returns the authenticated caller from the context. The zero
+// value is returned if the request was not authenticated.
+func GetPrincipal(ctx context.Context) Principal {
+	v, ok := ctx.Value(key).(*ContextValues)
+	if !ok {
+		return Principal{}
+	}
+	return v.Principal
+}
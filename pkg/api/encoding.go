@@ -0,0 +1,615 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Media types supported out of the box.
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeCSV     = "text/csv"
+	MediaTypeMsgpack = "application/msgpack"
+)
+
+// ErrUnsupportedValue is returned by an Encoder for values it cannot
+// represent, the next media type accepted by the client is then tried.
+var ErrUnsupportedValue = errors.New("value cannot be encoded in the media type")
+
+// Encoder writes the response envelope in one media type.
+type Encoder func(w io.Writer, v interface{}) error
+
+// Decoder reads a request body in one media type into val.
+type Decoder func(r io.Reader, val interface{}) error
+
+// encoder is a registered Encoder and the media type it produces.
+type encoder struct {
+	mediaType string
+	encode    Encoder
+}
+
+// encoders are tried in registration order, JSON first, so that wildcards
+// in Accept pick the earliest registered type.
+var encoders []encoder
+
+// decoders are looked up by the media type of the request body.
+var decoders = make(map[string]Decoder)
+
+func init() {
+	RegisterEncoder(MediaTypeJSON, encodeJSON)
+	RegisterEncoder(MediaTypeXML, encodeXML)
+	RegisterEncoder("text/xml", encodeXML)
+	RegisterEncoder(MediaTypeCSV, encodeCSV)
+	RegisterEncoder(MediaTypeMsgpack, encodeMsgpack)
+	RegisterEncoder("application/x-msgpack", encodeMsgpack)
+
+	RegisterDecoder(MediaTypeJSON, decodeJSON)
+	RegisterDecoder(MediaTypeXML, decodeXML)
+	RegisterDecoder("text/xml", decodeXML)
+	RegisterDecoder(MediaTypeMsgpack, decodeMsgpack)
+	RegisterDecoder("application/x-msgpack", decodeMsgpack)
+}
+
+// RegisterEncoder makes responses available in the media type, replacing
+// the encoder already registered for it. It must be called before the
+// API serves requests.
+func RegisterEncoder(mediaType string, enc Encoder) {
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].encode = enc
+			return
+		}
+	}
+	encoders = append(encoders, encoder{mediaType: mediaType, encode: enc})
+}
+
+// RegisterDecoder makes Decode accept request bodies of the media type,
+// replacing the decoder already registered for it. It must be called
+// before the API serves requests.
+func RegisterDecoder(mediaType string, dec Decoder) {
+	decoders[mediaType] = dec
+}
+
+// MediaTypes returns the media types responses can be encoded in.
+func MediaTypes() []string {
+	types := make([]string, len(encoders))
+	for i, enc := range encoders {
+		types[i] = enc.mediaType
+	}
+	return types
+}
+
+// Negotiate picks the media type of the response from an Accept header,
+// honoring the quality values. An empty header, and one accepting problem
+// details, accepts JSON. When none of the accepted types is supported it
+// returns JSON and false.
+func Negotiate(accept string) (string, bool) {
+	types := Acceptable(accept)
+	if len(types) == 0 {
+		return MediaTypeJSON, false
+	}
+	return types[0], true
+}
+
+// Acceptable returns the media types responses can be encoded in that the
+// Accept header allows, the preferred first. An empty header accepts JSON.
+func Acceptable(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{MediaTypeJSON}
+	}
+
+	type ranged struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []ranged
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, ranged{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	var types []string
+	seen := make(map[string]bool)
+	add := func(mediaType string) {
+		if !seen[mediaType] {
+			seen[mediaType] = true
+			types = append(types, mediaType)
+		}
+	}
+	for _, rng := range ranges {
+		// Problem details are JSON, the clients asking for them read JSON
+		// success bodies as well.
+		if rng.mediaType == MediaTypeProblemJSON {
+			add(MediaTypeJSON)
+			continue
+		}
+		for _, enc := range encoders {
+			if matchMediaRange(rng.mediaType, enc.mediaType) {
+				add(enc.mediaType)
+			}
+		}
+	}
+
+	return types
+}
+
+// matchMediaRange tells whether the media type falls in the range, which
+// can be */* or type/*.
+func matchMediaRange(mediaRange string, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// errNotAcceptable is returned by encode when none of the media types can
+// represent the value.
+var errNotAcceptable = errors.New("not acceptable")
+
+// encode writes v in the first of the media types able to represent it and
+// returns that type. It returns errNotAcceptable when none can.
+func encode(w io.Writer, mediaTypes []string, v interface{}) (string, error) {
+	for _, mediaType := range mediaTypes {
+		for _, enc := range encoders {
+			if enc.mediaType != mediaType {
+				continue
+			}
+			var buf bytes.Buffer
+			err := enc.encode(&buf, v)
+			if errors.Is(err, ErrUnsupportedValue) {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			_, err = buf.WriteTo(w)
+			return mediaType, err
+		}
+	}
+
+	return "", errNotAcceptable
+}
+
+// encodesAny tells whether one of the media types can carry any response,
+// which the empty success envelope stands for.
+func encodesAny(mediaTypes []string) bool {
+	_, err := encode(io.Discard, mediaTypes, SuccessResponse{Success: true})
+	return err == nil
+}
+
+// toDocument turns v into the generic value of its JSON form, so that
+// every media type carries the same names and values as JSON.
+func toDocument(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// -----------------------------------------------------------------------
+// JSON
+// -----------------------------------------------------------------------
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	jd, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jd)
+	return err
+}
+
+func decodeJSON(r io.Reader, val interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(val); err != nil {
+		// Checks if this is a bad key, or wrong value
+		var re = regexp.MustCompile(`(?m)field ([A-Za-z-_\.]+) (of type [A-Za-z]+)`)
+		matches := re.FindStringSubmatch(err.Error())
+		if len(matches) == 3 {
+			parts := strings.Split(matches[1], ".")
+			err = fmt.Errorf("invalid json: %s must be %s", parts[len(parts)-1], matches[2])
+			return NewRequestError(err, http.StatusBadRequest)
+		}
+
+		// Unknown Fields
+		if strings.Contains(err.Error(), "unknown field") {
+			str := strings.ReplaceAll(err.Error(), "\\", "")
+			str = strings.ReplaceAll(str, "\"", "")
+			str = strings.ReplaceAll(str, "unknown field", "unknown field:")
+			return NewRequestError(errors.New(str), http.StatusBadRequest)
+		}
+
+		// Don't die on a decode failure
+		return NewRequestError(err, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// -----------------------------------------------------------------------
+// XML
+// -----------------------------------------------------------------------
+
+// xmlName matches the JSON names usable as XML element names as is, the
+// others are written as <entry key="...">.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// xmlNode is an element of the XML form of a JSON document: objects hold
+// one element per member, arrays one <item> per element, and null values
+// are left out or marked nil="true".
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+// newXMLNode builds the element named after a JSON member from its value.
+func newXMLNode(name string, v interface{}) xmlNode {
+	n := xmlNode{XMLName: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		n.XMLName.Local = "entry"
+		n.Attrs = []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: "nil"}, Value: "true"})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k, val := range v {
+			if val != nil {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			n.Nodes = append(n.Nodes, newXMLNode(k, v[k]))
+		}
+	case []interface{}:
+		for _, item := range v {
+			n.Nodes = append(n.Nodes, newXMLNode("item", item))
+		}
+	default:
+		n.Text = fmt.Sprint(v)
+	}
+
+	return n
+}
+
+// name is the JSON member the element stands for.
+func (n xmlNode) name() string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == "key" {
+			return attr.Value
+		}
+	}
+	return n.XMLName.Local
+}
+
+// isNil tells whether the element is marked nil="true".
+func (n xmlNode) isNil() bool {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeXML writes the JSON form of v under a <response> element.
+func encodeXML(w io.Writer, v interface{}) error {
+	doc, err := toDocument(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(newXMLNode("response", doc))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeXML reads a document shaped like the responses, the fields of val
+// as elements of the root, into val. XML has no types, the text of every
+// element is typed after the field it fills, then decoded like a JSON body.
+func decodeXML(r io.Reader, val interface{}) error {
+	var root xmlNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return NewRequestError(errors.New("xml payload is empty"), http.StatusBadRequest)
+		}
+		return NewRequestError(fmt.Errorf("invalid xml: %w", err), http.StatusBadRequest)
+	}
+
+	b, err := json.Marshal(root.value(reflect.TypeOf(val)))
+	if err != nil {
+		return NewRequestError(fmt.Errorf("invalid xml: %w", err), http.StatusBadRequest)
+	}
+
+	return decodeJSON(bytes.NewReader(b), val)
+}
+
+// value is the JSON value of the element read as a t, nil when the type
+// is unknown.
+func (n xmlNode) value(t reflect.Type) interface{} {
+	if n.isNil() {
+		return nil
+	}
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t == timeType {
+		return strings.TrimSpace(n.Text)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		obj := make(map[string]interface{}, len(n.Nodes))
+		for _, child := range n.Nodes {
+			var ft reflect.Type
+			if t.Kind() == reflect.Map {
+				ft = t.Elem()
+			} else {
+				ft = jsonFieldType(t, child.name())
+			}
+			obj[child.name()] = child.value(ft)
+		}
+		return obj
+
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, len(n.Nodes))
+		for i, child := range n.Nodes {
+			items[i] = child.value(t.Elem())
+		}
+		return items
+
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// Text that is not a literal of the type is kept as a string, for
+		// the JSON decoder to report the field.
+		text := strings.TrimSpace(n.Text)
+		var lit interface{}
+		if json.Unmarshal([]byte(text), &lit) == nil {
+			return json.RawMessage(text)
+		}
+		return text
+
+	default:
+		return strings.TrimSpace(n.Text)
+	}
+}
+
+// jsonFieldType returns the type of the struct field named so in JSON, nil
+// if there is none.
+func jsonFieldType(t reflect.Type, name string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if res := jsonFieldType(ft, name); res != nil {
+					return res
+				}
+			}
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return f.Type
+		}
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------
+// CSV
+// -----------------------------------------------------------------------
+
+// encodeCSV writes the data of a listing with a header row built from the
+// field names of the rows. Cursors travel in the Link header. Anything but
+// a successful list of objects is unsupported.
+func encodeCSV(w io.Writer, v interface{}) error {
+	r, ok := v.(SuccessResponse)
+	if !ok || !r.Success || r.Data == nil {
+		return ErrUnsupportedValue
+	}
+
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(b, &rows); err != nil {
+		return ErrUnsupportedValue
+	}
+
+	// Fields left out of some rows, e.g. omitempty, still get a column.
+	var columns []string
+	seen := make(map[string]bool)
+	values := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		keys, err := objectKeys(row)
+		if err != nil {
+			return ErrUnsupportedValue
+		}
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+		if err := json.Unmarshal(row, &values[i]); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range values {
+		for i, col := range columns {
+			record[i] = csvCell(row[col])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// objectKeys returns the keys of a JSON object in document order.
+func objectKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("not an object")
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// csvCell is the text of a JSON value, null is empty and nested values are
+// kept as JSON.
+func csvCell(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// -----------------------------------------------------------------------
+// MessagePack
+// -----------------------------------------------------------------------
+
+// encodeMsgpack writes the JSON form of v as MessagePack.
+func encodeMsgpack(w io.Writer, v interface{}) error {
+	doc, err := toDocument(v)
+	if err != nil {
+		return err
+	}
+
+	return msgpack.NewEncoder(w).Encode(msgpackValue(doc))
+}
+
+// msgpackValue replaces the JSON numbers of the document by integers or
+// floats.
+func msgpackValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = msgpackValue(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = msgpackValue(val)
+		}
+	}
+	return v
+}
+
+// decodeMsgpack reads a MessagePack map into val, through its JSON form so
+// that the names and checks of JSON bodies apply.
+func decodeMsgpack(r io.Reader, val interface{}) error {
+	var doc interface{}
+	if err := msgpack.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return NewRequestError(errors.New("msgpack payload is empty"), http.StatusBadRequest)
+		}
+		return NewRequestError(fmt.Errorf("invalid msgpack: %w", err), http.StatusBadRequest)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return NewRequestError(errors.New("msgpack payload must be a map"), http.StatusBadRequest)
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return NewRequestError(fmt.Errorf("invalid msgpack: %w", err), http.StatusBadRequest)
+	}
+
+	return decodeJSON(bytes.NewReader(b), val)
+}
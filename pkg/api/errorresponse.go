@@ -15,6 +15,10 @@ type ErrorResponse struct {
 	//
 	//example: {"field": "error message for this specific field"}
 	Fields map[string]string `json:"fields,omitempty"`
+	// in:body
+	//
+	//example: validation_failed
+	Code string `json:"code,omitempty"`
 }
 
 // ErrorResponseID is the form used for API responses from failures in the API.
@@ -78,6 +82,12 @@ func (re *RequestError) Error() string {
 	return re.Err.Error()
 }
 
+// Unwrap returns the wrapped error, so the sentinel it carries can be
+// matched.
+func (re *RequestError) Unwrap() error {
+	return re.Err
+}
+
 // IsRequestError checks if the error type RequestError Exists
 func IsRequestError(err error) bool {
 	var re *RequestError
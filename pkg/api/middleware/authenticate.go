@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/auth"
+)
+
+// Authenticate identifies the caller using a JWT bearer token or an api key
+// and stores the principal in the context. Unauthenticated calls get a
+// uniform 401, the reason is only logged. A nil Auth disables
+// authentication and no middleware is returned.
+func Authenticate(log *slog.Logger, a *auth.Auth) api.Middleware {
+	if a == nil {
+		return nil
+	}
+
+	// This is the actual middleware function to be executed.
+	m := func(handler api.Handler) api.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			p, err := a.Authenticate(r)
+			if err != nil {
+				log.Info("authentication failed", "tracer_uid", api.GetTracerUID(ctx), slog.Any("ERROR", err))
+				w.Header().Set("WWW-Authenticate", `Bearer realm="employee-service"`)
+				return api.NewRequestError(auth.ErrUnauthenticated, http.StatusUnauthorized)
+			}
+
+			if err := api.SetPrincipal(ctx, p); err != nil {
+				return api.NewShutdownError("api value missing from context")
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+		return h
+	}
+	return m
+}
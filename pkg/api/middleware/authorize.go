@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/auth"
+)
+
+// Authorize checks the authenticated principal against the policy for the
+// given action. Denied calls get a 403. It must run after Authenticate. A nil
+// Policy disables authorization and no middleware is returned.
+func Authorize(log *slog.Logger, p *auth.Policy, action string) api.Middleware {
+	if p == nil {
+		return nil
+	}
+
+	// This is the actual middleware function to be executed.
+	m := func(handler api.Handler) api.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			principal := api.GetPrincipal(ctx)
+			if err := p.Authorize(principal, action); err != nil {
+				log.Info("authorization denied", "tracer_uid", api.GetTracerUID(ctx), "principal", principal.Subject, "roles", principal.Roles, "action", action)
+				if errors.Is(err, auth.ErrForbidden) {
+					return api.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+				}
+				return err
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+		return h
+	}
+	return m
+}
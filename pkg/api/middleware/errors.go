@@ -10,6 +10,9 @@ import (
 	"github.com/pansachin/employee-service/pkg/validate"
 )
 
+// validationFailed is the error code of field errors.
+const validationFailed = "validation_failed"
+
 // Errors handles errors coming out of the call chain. It detects normal
 // application errors which are used to respond to the client in a uniform way.
 // Unexpected errors (status >= 500) are logged.
@@ -44,6 +47,10 @@ func Errors(log *slog.Logger) api.Middleware {
 					reqErr := database.GetError(err)
 					er = api.ErrorResponse{
 						Error: reqErr.Error(),
+						Code:  api.ErrorCode(err, reqErr.Status),
+					}
+					if reqErr.Field != "" {
+						er.Fields = map[string]string{reqErr.Field: reqErr.Error()}
 					}
 					status = reqErr.Status
 
@@ -56,6 +63,7 @@ func Errors(log *slog.Logger) api.Middleware {
 					er = api.ErrorResponse{
 						Error:  errMsg,
 						Fields: fieldErrors.Fields(),
+						Code:   validationFailed,
 					}
 					status = http.StatusBadRequest
 
@@ -63,13 +71,18 @@ func Errors(log *slog.Logger) api.Middleware {
 					reqErr := api.GetRequestError(err)
 					er = api.ErrorResponse{
 						Error: reqErr.Error(),
+						Code:  api.ErrorCode(err, reqErr.Status),
 					}
 					status = reqErr.Status
 
 				default:
+					// Unexpected errors, including recovered panics, can carry
+					// internal details such as stack traces. Those only belong
+					// in the logs, the client gets a generic message.
 					status = http.StatusInternalServerError
 					er = api.ErrorResponse{
-						Error: err.Error(),
+						Error: http.StatusText(http.StatusInternalServerError),
+						Code:  api.StatusCode(http.StatusInternalServerError),
 					}
 				}
 
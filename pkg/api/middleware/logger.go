@@ -52,6 +52,8 @@ func Logger(log *slog.Logger) api.Middleware {
 			lw.Info("request completed",
 				"duration_s", s,
 				"response", v.StatusCode,
+				"principal", v.Principal.Subject,
+				"auth_method", v.Principal.Method,
 			)
 
 			return err
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/metrics"
+)
+
+// Metrics updates the request counters and latency histograms using the
+// values collected in the context during the request.
+func Metrics() api.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler api.Handler) api.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			v, err := api.GetContextValues(ctx)
+			if err != nil {
+				return api.NewShutdownError("api value missing from context")
+			}
+
+			// Call the next handler.
+			err = handler(ctx, w, r)
+
+			// Errors that are not handled yet end up as a 500.
+			status := v.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+				if err != nil {
+					status = http.StatusInternalServerError
+				}
+			}
+			code := strconv.Itoa(status)
+
+			metrics.Requests.WithLabelValues(r.Method, v.Path, code).Inc()
+			metrics.RequestDuration.WithLabelValues(r.Method, v.Path, code).Observe(time.Since(v.Now).Seconds())
+
+			if v.IsError || err != nil {
+				metrics.Errors.WithLabelValues(r.Method, v.Path).Inc()
+			}
+			if v.IsPanic {
+				metrics.Panics.WithLabelValues(r.Method, v.Path).Inc()
+			}
+
+			return err
+		}
+		return h
+	}
+	return m
+}
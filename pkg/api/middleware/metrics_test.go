@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/api/middleware"
+	"github.com/pansachin/employee-service/pkg/metrics"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+func Test_Metrics(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := api.NewAPI(log, nil, middleware.Metrics(), middleware.Errors(log), middleware.Panics())
+
+	a.Handle(http.MethodGet, "/metrics-test/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		switch api.Param(r, "id") {
+		case "missing":
+			return api.NewRequestError(errors.New("not found"), http.StatusNotFound)
+		case "panic":
+			panic("something unexpected")
+		}
+		return api.Respond(ctx, w, []string{"ok"}, http.StatusOK)
+	})
+
+	const route = "/metrics-test/{id}"
+	for _, id := range []string{"1", "2", "missing", "panic"} {
+		a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/"+id, nil))
+	}
+
+	t.Log("Given the need to count requests by route template and status")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen requests succeed, fail and panic.", testID)
+		{
+			counts := []struct {
+				status string
+				want   float64
+			}{
+				{"200", 2},
+				{"404", 1},
+				{"500", 1},
+			}
+			for _, c := range counts {
+				if got := testutil.ToFloat64(metrics.Requests.WithLabelValues(http.MethodGet, route, c.status)); got != c.want {
+					t.Fatalf("\t%s\tTest %d:\tShould count %v requests with status %s : %v", Failed, testID, c.want, c.status, got)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould count the requests by route template and status", Success, testID)
+
+			if got := testutil.ToFloat64(metrics.Errors.WithLabelValues(http.MethodGet, route)); got != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould count 2 errors : %v", Failed, testID, got)
+			}
+			if got := testutil.ToFloat64(metrics.Panics.WithLabelValues(http.MethodGet, route)); got != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould count 1 panic : %v", Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count the errors and panics", Success, testID)
+
+			w := httptest.NewRecorder()
+			metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			want := `employee_service_http_requests_total{method="GET",route="/metrics-test/{id}",status="404"} 1`
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+				t.Fatalf("\t%s\tTest %d:\tShould expose the counts in the Prometheus text format : %d\n%s", Failed, testID, w.Code, w.Body)
+			}
+			t.Logf("\t%s\tTest %d:\tShould expose the counts in the Prometheus text format", Success, testID)
+		}
+	}
+}
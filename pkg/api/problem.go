@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MediaTypeProblemJSON is the media type of RFC 7807 problem details.
+const MediaTypeProblemJSON = "application/problem+json"
+
+// problemTypePrefix prefixes the error code to form the type of a problem.
+const problemTypePrefix = "urn:employee-service:problem:"
+
+// Problem is an error response in the RFC 7807 problem details format.
+//
+// swagger:model Problem
+type Problem struct {
+	// URI identifying the kind of problem
+	//
+	// example: urn:employee-service:problem:employee_not_found
+	Type string `json:"type"`
+	// Short summary of the kind of problem
+	//
+	// example: Not Found
+	Title string `json:"title"`
+	// HTTP status code
+	//
+	// example: 404
+	Status int `json:"status"`
+	// Explanation of this occurrence of the problem
+	//
+	// example: employee not found
+	Detail string `json:"detail,omitempty"`
+	// Path of the request the problem occurred on
+	//
+	// example: /v1/employee/923498273
+	Instance string `json:"instance,omitempty"`
+	// Stable machine-readable error code
+	//
+	// example: employee_not_found
+	Code string `json:"code,omitempty"`
+	// Field errors
+	//
+	// example: {"name": "name is a required field"}
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// errorCode is a registered error code and the sentinel it stands for.
+type errorCode struct {
+	err  error
+	code string
+}
+
+// errorCodes are matched in registration order.
+var errorCodes []errorCode
+
+// RegisterErrorCode gives the error, and every error wrapping it, a stable
+// code clients can rely on instead of the message. It replaces the code
+// already registered for it and must be called before the API serves
+// requests.
+func RegisterErrorCode(err error, code string) {
+	for i := range errorCodes {
+		if errorCodes[i].err == err {
+			errorCodes[i].code = code
+			return
+		}
+	}
+	errorCodes = append(errorCodes, errorCode{err: err, code: code})
+}
+
+// ErrorCode returns the code of the first registered error err wraps. Other
+// errors get a code named after the status, e.g. not_found.
+func ErrorCode(err error, status int) string {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return StatusCode(status)
+}
+
+// StatusCode is the error code of a status, its text in snake case.
+func StatusCode(status int) string {
+	text := strings.ToLower(http.StatusText(status))
+	if text == "" {
+		return "error"
+	}
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	return text
+}
+
+// acceptsProblem tells whether the Accept header lists problem details.
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != MediaTypeProblemJSON {
+			continue
+		}
+		return params["q"] == "" || strings.Trim(params["q"], "0.") != ""
+	}
+	return false
+}
+
+// toProblem turns the error response into problem details.
+func toProblem(er ErrorResponse, status int, instance string) Problem {
+	code := er.Code
+	if code == "" {
+		code = StatusCode(status)
+	}
+
+	return Problem{
+		Type:     problemTypePrefix + code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   er.Error,
+		Instance: instance,
+		Code:     code,
+		Errors:   er.Fields,
+	}
+}
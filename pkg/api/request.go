@@ -2,10 +2,9 @@ package api
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
-	"regexp"
 	"strings"
 
 	//nolint:all
@@ -18,39 +17,34 @@ func Param(r *http.Request, key string) string {
 	return value
 }
 
-// Decode reads the body of an HTTP request looking for a JSON document. The
-// body is decoded into the provided value
+// Decode reads the body of an HTTP request in the media type named by its
+// Content-Type, JSON when there is none. The body is decoded into the
+// provided value. Every error is a RequestError carrying the status to
+// answer with, 415 for unsupported media types and 400 for invalid bodies,
+// handlers return it as is.
 // If the provided value is a struct then it is checked for validation tags
 func Decode(r *http.Request, val interface{}) error {
-	if err := checkPayload(r); err != nil {
-		return err
+	mediaType := MediaTypeJSON
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return NewRequestError(fmt.Errorf("invalid content type: %w", err), http.StatusUnsupportedMediaType)
+		}
+		mediaType = mt
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
-	if err := decoder.Decode(val); err != nil {
-		// Checks if this is a bad key, or wrong value
-		var re = regexp.MustCompile(`(?m)field ([A-Za-z-_\.]+) (of type [A-Za-z]+)`)
-		matches := re.FindStringSubmatch(err.Error())
-		if len(matches) == 3 {
-			parts := strings.Split(matches[1], ".")
-			err = fmt.Errorf("invalid json: %s must be %s", parts[len(parts)-1], matches[2])
-			return NewRequestError(err, http.StatusBadRequest)
-		}
+	dec, ok := decoders[mediaType]
+	if !ok {
+		return NewRequestError(fmt.Errorf("content type %s is not supported", mediaType), http.StatusUnsupportedMediaType)
+	}
 
-		// Unknown Fields
-		if strings.Contains(err.Error(), "unknown field") {
-			str := strings.ReplaceAll(err.Error(), "\\", "")
-			str = strings.ReplaceAll(str, "\"", "")
-			str = strings.ReplaceAll(str, "unknown field", "unknown field:")
-			return NewRequestError(fmt.Errorf(str), http.StatusBadRequest)
+	if mediaType == MediaTypeJSON {
+		if err := checkPayload(r); err != nil {
+			return err
 		}
-
-		// Don't die on a decode failure
-		return NewRequestError(err, http.StatusBadRequest)
 	}
 
-	return nil
+	return dec(r.Body, val)
 }
 
 func checkPayload(r *http.Request) error {
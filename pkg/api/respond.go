@@ -1,11 +1,15 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -27,12 +31,63 @@ type SuccessResponse struct {
 	// Errors
 	// in: body
 	Errors interface{} `json:"errors,omitempty"`
+	// Cursor to the next page of a listing
+	//
+	// example: eyJzIjoiY3JlYXRlZF9vbiJ9.c2lnbmF0dXJl
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Cursor to the previous page of a listing
+	//
+	// example: eyJzIjoiY3JlYXRlZF9vbiJ9.c2lnbmF0dXJl
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Meta describes the page of a listing
+	// in: body
+	Meta interface{} `json:"meta,omitempty"`
+}
+
+// Page is one page of a listing together with the cursors to the pages
+// around it and, optionally, a description of the page.
+type Page struct {
+	Data       interface{}
+	NextCursor string
+	PrevCursor string
+	Meta       interface{}
+}
+
+// RespondPage returns a page of a listing to the client. The cursors are
+// also sent as RFC 8288 Link headers built from the request URL.
+func RespondPage(ctx context.Context, w http.ResponseWriter, r *http.Request, page Page, statusCode int) error {
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, cursorURL(r, page.NextCursor)))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, cursorURL(r, page.PrevCursor)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	return Respond(ctx, w, page, statusCode)
+}
+
+// cursorURL is the request URL moved to the cursor. Offset paging
+// parameters are dropped as the cursor replaces them.
+func cursorURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Del("page")
+	q.Del("sort")
+	q.Del("direction")
+	q.Set("cursor", cursor)
+
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return u.String()
 }
 
 // Respond returns json to client
 func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error {
 
 	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "pkg.api.respond")
+	defer span.End()
 	span.SetAttributes(attribute.Int("statusCode", statusCode))
 
 	// Set the status code for the request logger middleware
@@ -41,6 +96,34 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 		return err
 	}
 
+	// A 304 must not carry a body, the client already has the data.
+	if statusCode == http.StatusNotModified {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	v, err := GetContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Errors are sent as problem details to the clients preferring them
+	if er, ok := data.(ErrorResponse); ok && v.Problem {
+		jd, err := json.Marshal(toProblem(er, statusCode, v.RequestPath))
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", MediaTypeProblemJSON)
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(statusCode)
+
+		if _, err := w.Write(jd); err != nil {
+			return fmt.Errorf("write fail: %w", err)
+		}
+		return nil
+	}
+
 	// If no data is provided, just return status code -- Always return something
 	//if statusCode == http.StatusNoContent {
 	//	w.WriteHeader(statusCode)
@@ -52,6 +135,14 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 		Timestamp: time.Now().UTC().Unix(),
 		Data:      data,
 	}
+	// Pages carry their cursors in the envelope
+	if page, ok := data.(Page); ok {
+		r.Data = page.Data
+		r.NextCursor = page.NextCursor
+		r.PrevCursor = page.PrevCursor
+		r.Meta = page.Meta
+	}
+
 	// If it's an error, it does not need to re-marshal
 	if reflect.TypeOf(data) == reflect.TypeOf(ErrorResponse{}) {
 		r.Success = false
@@ -59,19 +150,30 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 		r.Errors = data
 	}
 
-	// Convert the response to json
-	jd, err := json.Marshal(r)
+	// Encode the response in the preferred media type able to carry it.
+	// Errors fall back to JSON rather than hide the error behind a 406.
+	var body bytes.Buffer
+	mediaTypes := v.MediaTypes
+	if r.Errors != nil {
+		mediaTypes = append(mediaTypes[:len(mediaTypes):len(mediaTypes)], MediaTypeJSON)
+	}
+	mediaType, err := encode(&body, mediaTypes, r)
 	if err != nil {
+		if errors.Is(err, errNotAcceptable) {
+			err = fmt.Errorf("the response cannot be sent as %s", strings.Join(v.MediaTypes, ", "))
+			return NewRequestError(err, http.StatusNotAcceptable)
+		}
 		return err
 	}
 
-	// set the content type now that we know there was no marshal error
-	w.Header().Set("Content-Type", "application/json")
+	// set the content type now that we know there was no encoding error
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
 	w.WriteHeader(statusCode)
 
 	// Send the result back to the client
-	if _, err := w.Write(jd); err != nil {
-		return fmt.Errorf("write fail: %+v", jd)
+	if _, err := body.WriteTo(w); err != nil {
+		return fmt.Errorf("write fail: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,314 @@
+// Package auth for authenticating callers of the api
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/pansachin/employee-service/pkg/api"
+)
+
+// Set of error variables for authentication.
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrNoCredentials   = errors.New("no credentials provided")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+	ErrUnknownKey      = errors.New("unknown signing key")
+	ErrUnknownCert     = errors.New("client certificate not allowed")
+)
+
+// Authentication methods reported in the principal.
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+	MethodMTLS   = "mtls"
+)
+
+// APIKeyHeader is the header carrying a static api key.
+const APIKeyHeader = "X-API-Key"
+
+// leeway is the clock skew tolerated when validating token times.
+const leeway = 30 * time.Second
+
+// Config holds the authentication configuration.
+type Config struct {
+	// Issuer, if set, must match the iss claim of the tokens.
+	Issuer string
+
+	// Audience, if set, must be part of the aud claim of the tokens.
+	Audience string
+
+	// HMACSecretFile is the path to the shared secret used for HS256 tokens.
+	HMACSecretFile string
+
+	// PublicKeyFiles are paths to PEM encoded RSA public keys used for RS256
+	// tokens. The file name without extension is used as key id.
+	PublicKeyFiles []string
+
+	// JWKSFile is the path to a JSON Web Key Set holding RSA public keys
+	// used for RS256 tokens.
+	JWKSFile string
+
+	// APIKeys are the static api keys accepted as an alternative to tokens.
+	APIKeys []APIKey
+
+	// ClientCerts map verified client certificates to roles. Calls without
+	// a token or api key are authenticated with the client certificate.
+	ClientCerts []CertRule
+}
+
+// APIKey is a static api key. Only the hex encoded SHA-256 hash of the key
+// is kept in the configuration.
+type APIKey struct {
+	Name  string
+	Hash  string
+	Roles []string
+}
+
+// CertRule grants roles to client certificates matching the organizational
+// unit and common name. An empty field matches any value but at least one
+// of them must be set.
+type CertRule struct {
+	OU    string
+	CN    string
+	Roles []string
+}
+
+// Claims are the claims expected in the tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+// Auth authenticates requests using JWT bearer tokens, static api keys or
+// client certificates.
+type Auth struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	apiKeys    []APIKey
+	certRules  []CertRule
+	parser     *jwt.Parser
+}
+
+// New constructs an Auth loading the keys referenced in the configuration.
+func New(cfg Config) (*Auth, error) {
+	a := Auth{
+		rsaKeys: make(map[string]*rsa.PublicKey),
+	}
+
+	if cfg.HMACSecretFile != "" {
+		secret, err := os.ReadFile(cfg.HMACSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading hmac secret: %w", err)
+		}
+		a.hmacSecret = []byte(strings.TrimSpace(string(secret)))
+		if len(a.hmacSecret) == 0 {
+			return nil, fmt.Errorf("hmac secret %s is empty", cfg.HMACSecretFile)
+		}
+	}
+
+	for _, file := range cfg.PublicKeyFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %s: %w", file, err)
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		a.rsaKeys[kid] = key
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading jwks: %w", err)
+		}
+		for kid, key := range keys {
+			a.rsaKeys[kid] = key
+		}
+	}
+
+	for _, k := range cfg.APIKeys {
+		if _, err := hex.DecodeString(k.Hash); err != nil || len(k.Hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("api key %q: hash must be a hex encoded sha256", k.Name)
+		}
+		k.Hash = strings.ToLower(k.Hash)
+		a.apiKeys = append(a.apiKeys, k)
+	}
+
+	for _, rule := range cfg.ClientCerts {
+		if rule.OU == "" && rule.CN == "" {
+			return nil, errors.New("client cert rule needs an ou or a cn")
+		}
+		a.certRules = append(a.certRules, rule)
+	}
+
+	var methods []string
+	if a.hmacSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(a.rsaKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 && len(a.apiKeys) == 0 && len(a.certRules) == 0 {
+		return nil, errors.New("no signing keys, api keys or client cert rules configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return &a, nil
+}
+
+// Authenticate identifies the caller of the request from either the bearer
+// token in the Authorization header, the api key header or, when neither is
+// present, the verified client certificate.
+func (a *Auth) Authenticate(r *http.Request) (api.Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return api.Principal{}, ErrNoCredentials
+		}
+		return a.authenticateJWT(strings.TrimSpace(token))
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return a.authenticateCert(r.TLS.VerifiedChains[0][0])
+	}
+
+	return api.Principal{}, ErrNoCredentials
+}
+
+// authenticateJWT validates the token and returns the principal it carries.
+func (a *Auth) authenticateJWT(token string) (api.Principal, error) {
+	var claims Claims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.keyFunc); err != nil {
+		return api.Principal{}, fmt.Errorf("validating token: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return api.Principal{}, errors.New("validating token: missing subject")
+	}
+
+	return api.Principal{
+		Subject: claims.Subject,
+		Roles:   claims.Roles,
+		Method:  MethodJWT,
+	}, nil
+}
+
+// keyFunc selects the key used to verify the token signature.
+func (a *Auth) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hmacSecret, nil
+
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := a.rsaKeys[kid]; ok {
+			return key, nil
+		}
+
+		// Tokens without a kid are accepted when there is no ambiguity.
+		if kid == "" && len(a.rsaKeys) == 1 {
+			for _, key := range a.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+
+	return nil, fmt.Errorf("%w: alg %q", ErrUnknownKey, t.Method.Alg())
+}
+
+// authenticateAPIKey compares the hash of the key against the configured
+// hashes in constant time.
+func (a *Auth) authenticateAPIKey(key string) (api.Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	hash := []byte(hex.EncodeToString(sum[:]))
+
+	var match *APIKey
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(a.apiKeys[i].Hash)) == 1 {
+			match = &a.apiKeys[i]
+		}
+	}
+	if match == nil {
+		return api.Principal{}, ErrInvalidAPIKey
+	}
+
+	return api.Principal{
+		Subject: match.Name,
+		Roles:   match.Roles,
+		Method:  MethodAPIKey,
+	}, nil
+}
+
+// authenticateCert maps the organizational units and common name of a
+// verified client certificate to roles using the configured rules.
+func (a *Auth) authenticateCert(cert *x509.Certificate) (api.Principal, error) {
+	cn := cert.Subject.CommonName
+	ous := cert.Subject.OrganizationalUnit
+
+	var roles []string
+	var matched bool
+	for _, rule := range a.certRules {
+		if rule.CN != "" && rule.CN != cn {
+			continue
+		}
+		if rule.OU != "" && !slices.Contains(ous, rule.OU) {
+			continue
+		}
+		matched = true
+		for _, role := range rule.Roles {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if !matched {
+		return api.Principal{}, fmt.Errorf("%w: ou %v cn %q", ErrUnknownCert, ous, cn)
+	}
+
+	return api.Principal{
+		Subject: cn,
+		Roles:   roles,
+		Method:  MethodMTLS,
+	}, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of an api key, the form
+// in which keys are stored in the configuration.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
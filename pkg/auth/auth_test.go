@@ -0,0 +1,275 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/pansachin/employee-service/pkg/auth"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+const (
+	hmacSecret = "super-secret-for-tests"
+	apiKey     = "an-api-key"
+	issuer     = "https://issuer.test"
+)
+
+// keys holds the material used to sign and verify tokens in the tests.
+type keys struct {
+	rsa      *rsa.PrivateKey
+	jwksRSA  *rsa.PrivateKey
+	hmacFile string
+	pemFile  string
+	jwksFile string
+}
+
+func newKeys(t *testing.T) keys {
+	t.Helper()
+	dir := t.TempDir()
+
+	k := keys{
+		hmacFile: filepath.Join(dir, "hmac.secret"),
+		pemFile:  filepath.Join(dir, "pem-key.pem"),
+		jwksFile: filepath.Join(dir, "jwks.json"),
+	}
+
+	var err error
+	if k.rsa, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	if k.jwksRSA, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+
+	if err := os.WriteFile(k.hmacFile, []byte(hmacSecret+"\n"), 0o600); err != nil {
+		t.Fatalf("writing hmac secret: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&k.rsa.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling public key: %v", err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(k.pemFile, pub, 0o600); err != nil {
+		t.Fatalf("writing public key: %v", err)
+	}
+
+	set := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "jwks-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(k.jwksRSA.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.jwksRSA.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(set)
+	if err := os.WriteFile(k.jwksFile, data, 0o600); err != nil {
+		t.Fatalf("writing jwks: %v", err)
+	}
+
+	return k
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims auth.Claims) string {
+	t.Helper()
+
+	tkn := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tkn.Header["kid"] = kid
+	}
+	str, err := tkn.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return str
+}
+
+func clientCert(ou string, cn string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			OrganizationalUnit: []string{ou},
+			CommonName:         cn,
+		},
+	}
+}
+
+func claims(sub string, exp time.Duration, roles ...string) auth.Claims {
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			Issuer:    issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+		},
+		Roles: roles,
+	}
+}
+
+func Test_Authenticate(t *testing.T) {
+	k := newKeys(t)
+
+	a, err := auth.New(auth.Config{
+		Issuer:         issuer,
+		HMACSecretFile: k.hmacFile,
+		PublicKeyFiles: []string{k.pemFile},
+		JWKSFile:       k.jwksFile,
+		APIKeys: []auth.APIKey{
+			{Name: "reporting", Hash: auth.HashAPIKey(apiKey), Roles: []string{"viewer"}},
+		},
+		ClientCerts: []auth.CertRule{
+			{OU: "payroll", CN: "payroll-service", Roles: []string{"viewer"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct auth : %s", Failed, err)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	wrongIssuer := claims("alice", time.Hour)
+	wrongIssuer.Issuer = "https://someone.else"
+
+	cases := []struct {
+		name    string
+		header  string
+		value   string
+		subject string
+		method  string
+		cert    *x509.Certificate
+		wantErr bool
+	}{
+		{
+			name:    "HS256 token",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), claims("alice", time.Hour, "hr-admin")),
+			subject: "alice",
+			method:  auth.MethodJWT,
+		},
+		{
+			name:    "RS256 token signed with a PEM key",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodRS256, "pem-key", k.rsa, claims("bob", time.Hour)),
+			subject: "bob",
+			method:  auth.MethodJWT,
+		},
+		{
+			name:    "RS256 token signed with a JWKS key",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodRS256, "jwks-key", k.jwksRSA, claims("carol", time.Hour)),
+			subject: "carol",
+			method:  auth.MethodJWT,
+		},
+		{
+			name:    "api key",
+			header:  auth.APIKeyHeader,
+			value:   apiKey,
+			subject: "reporting",
+			method:  auth.MethodAPIKey,
+		},
+		{
+			name:    "client certificate",
+			cert:    clientCert("payroll", "payroll-service"),
+			subject: "payroll-service",
+			method:  auth.MethodMTLS,
+		},
+		{
+			name:    "client certificate from another unit",
+			cert:    clientCert("marketing", "payroll-service"),
+			wantErr: true,
+		},
+		{
+			name:    "no credentials",
+			wantErr: true,
+		},
+		{
+			name:    "unknown api key",
+			header:  auth.APIKeyHeader,
+			value:   "not-a-key",
+			wantErr: true,
+		},
+		{
+			name:    "expired token",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), claims("alice", -time.Hour)),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), wrongIssuer),
+			wantErr: true,
+		},
+		{
+			name:    "token signed with an unknown key",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodRS256, "pem-key", other, claims("mallory", time.Hour)),
+			wantErr: true,
+		},
+		{
+			name:    "token with a wrong secret",
+			header:  "Authorization",
+			value:   "Bearer " + sign(t, jwt.SigningMethodHS256, "", []byte("guessed"), claims("mallory", time.Hour)),
+			wantErr: true,
+		},
+	}
+
+	t.Log("Given the need to authenticate callers")
+	for testID, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/v1/employee", nil)
+		if tc.header != "" {
+			r.Header.Set(tc.header, tc.value)
+		}
+		if tc.cert != nil {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tc.cert}}}
+		}
+
+		p, err := a.Authenticate(r)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("\t%s\tTest %d [%s]:\tShould NOT authenticate, got %q", Failed, testID, tc.name, p.Subject)
+			}
+			t.Logf("\t%s\tTest %d [%s]:\tShould NOT authenticate", Success, testID, tc.name)
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould authenticate : %s", Failed, testID, tc.name, err)
+		}
+		if p.Subject != tc.subject || p.Method != tc.method {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould get principal %s/%s, got %s/%s", Failed, testID, tc.name, tc.subject, tc.method, p.Subject, p.Method)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould authenticate as %s", Success, testID, tc.name, tc.subject)
+	}
+}
+
+func Test_NewWithoutKeys(t *testing.T) {
+	if _, err := auth.New(auth.Config{}); err == nil {
+		t.Fatalf("\t%s\tTest 1:\tShould fail without keys", Failed)
+	}
+	t.Logf("\t%s\tTest 1:\tShould fail without keys", Success)
+
+	_, err := auth.New(auth.Config{APIKeys: []auth.APIKey{{Name: "plain", Hash: "not-a-hash"}}})
+	if err == nil {
+		t.Fatalf("\t%s\tTest 2:\tShould reject api keys that are not hashed", Failed)
+	}
+	t.Logf("\t%s\tTest 2:\tShould reject api keys that are not hashed", Success)
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pansachin/employee-service/pkg/api"
+)
+
+// ErrForbidden is returned when the caller lacks a role for the action.
+var ErrForbidden = errors.New("not allowed to perform this action")
+
+// Set of roles known to the service.
+const (
+	RoleViewer   = "viewer"
+	RoleHREditor = "hr-editor"
+	RoleHRAdmin  = "hr-admin"
+)
+
+// Set of actions that can be authorized.
+const (
+	ActionEmployeeRead     = "employee:read"
+	ActionEmployeeCreate   = "employee:create"
+	ActionEmployeeUpdate   = "employee:update"
+	ActionEmployeeDelete   = "employee:delete"
+	ActionEmployeeUndelete = "employee:undelete"
+	ActionEmployeePurge    = "employee:purge"
+	ActionEmployeeHistory  = "employee:history"
+	ActionEmployeeBatch    = "employee:batch"
+
+	ActionDepartmentRead     = "department:read"
+	ActionDepartmentCreate   = "department:create"
+	ActionDepartmentUpdate   = "department:update"
+	ActionDepartmentDelete   = "department:delete"
+	ActionDepartmentUndelete = "department:undelete"
+
+	ActionPositionRead     = "position:read"
+	ActionPositionCreate   = "position:create"
+	ActionPositionUpdate   = "position:update"
+	ActionPositionDelete   = "position:delete"
+	ActionPositionUndelete = "position:undelete"
+)
+
+// adminOnly lists the actions that can never be granted to another role.
+var adminOnly = []string{
+	ActionEmployeeUndelete,
+	ActionEmployeePurge,
+	ActionDepartmentUndelete,
+	ActionPositionUndelete,
+}
+
+// DefaultPolicies returns the roles allowed for each action when the
+// configuration does not override them.
+func DefaultPolicies() map[string][]string {
+	return map[string][]string{
+		ActionEmployeeRead:     {RoleViewer, RoleHREditor, RoleHRAdmin},
+		ActionEmployeeCreate:   {RoleHREditor, RoleHRAdmin},
+		ActionEmployeeUpdate:   {RoleHREditor, RoleHRAdmin},
+		ActionEmployeeDelete:   {RoleHREditor, RoleHRAdmin},
+		ActionEmployeeUndelete: {RoleHRAdmin},
+		ActionEmployeePurge:    {RoleHRAdmin},
+		ActionEmployeeHistory:  {RoleHREditor, RoleHRAdmin},
+		ActionEmployeeBatch:    {RoleHREditor, RoleHRAdmin},
+
+		ActionDepartmentRead:     {RoleViewer, RoleHREditor, RoleHRAdmin},
+		ActionDepartmentCreate:   {RoleHRAdmin},
+		ActionDepartmentUpdate:   {RoleHRAdmin},
+		ActionDepartmentDelete:   {RoleHRAdmin},
+		ActionDepartmentUndelete: {RoleHRAdmin},
+
+		ActionPositionRead:     {RoleViewer, RoleHREditor, RoleHRAdmin},
+		ActionPositionCreate:   {RoleHRAdmin},
+		ActionPositionUpdate:   {RoleHRAdmin},
+		ActionPositionDelete:   {RoleHRAdmin},
+		ActionPositionUndelete: {RoleHRAdmin},
+	}
+}
+
+// Policy maps every action to the roles allowed to perform it.
+type Policy struct {
+	rules map[string][]string
+}
+
+// NewPolicy constructs a Policy from the default policies overridden by the
+// provided ones. Admin only actions cannot be granted to other roles.
+func NewPolicy(policies map[string][]string) (*Policy, error) {
+	rules := DefaultPolicies()
+	for action, roles := range policies {
+		rules[action] = roles
+	}
+
+	for _, action := range adminOnly {
+		for _, role := range rules[action] {
+			if role != RoleHRAdmin {
+				return nil, fmt.Errorf("action %q is admin only, it cannot be granted to role %q", action, role)
+			}
+		}
+	}
+
+	return &Policy{rules: rules}, nil
+}
+
+// Authorize checks whether the principal holds one of the roles allowed to
+// perform the action. Unknown actions are denied.
+func (p *Policy) Authorize(principal api.Principal, action string) error {
+	for _, role := range p.rules[action] {
+		if slices.Contains(principal.Roles, role) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrForbidden, action)
+}
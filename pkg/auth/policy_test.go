@@ -0,0 +1,56 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/auth"
+)
+
+func Test_Policy(t *testing.T) {
+	p, err := auth.NewPolicy(map[string][]string{
+		auth.ActionEmployeeRead: {auth.RoleViewer, "auditor"},
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the policy : %s", Failed, err)
+	}
+
+	cases := []struct {
+		name    string
+		roles   []string
+		action  string
+		allowed bool
+	}{
+		{name: "viewer reads", roles: []string{auth.RoleViewer}, action: auth.ActionEmployeeRead, allowed: true},
+		{name: "configured role reads", roles: []string{"auditor"}, action: auth.ActionEmployeeRead, allowed: true},
+		{name: "viewer creates", roles: []string{auth.RoleViewer}, action: auth.ActionEmployeeCreate},
+		{name: "editor updates", roles: []string{auth.RoleHREditor}, action: auth.ActionEmployeeUpdate, allowed: true},
+		{name: "editor undeletes", roles: []string{auth.RoleHREditor}, action: auth.ActionEmployeeUndelete},
+		{name: "admin undeletes", roles: []string{auth.RoleHRAdmin}, action: auth.ActionEmployeeUndelete, allowed: true},
+		{name: "viewer reads departments", roles: []string{auth.RoleViewer}, action: auth.ActionDepartmentRead, allowed: true},
+		{name: "editor creates departments", roles: []string{auth.RoleHREditor}, action: auth.ActionDepartmentCreate},
+		{name: "admin deletes departments", roles: []string{auth.RoleHRAdmin}, action: auth.ActionDepartmentDelete, allowed: true},
+		{name: "no roles", action: auth.ActionEmployeeRead},
+		{name: "unknown action", roles: []string{auth.RoleHRAdmin}, action: "employee:unknown"},
+	}
+
+	t.Log("Given the need to authorize callers by role")
+	for testID, tc := range cases {
+		err := p.Authorize(api.Principal{Subject: "someone", Roles: tc.roles}, tc.action)
+		if tc.allowed != (err == nil) || (err != nil && !errors.Is(err, auth.ErrForbidden)) {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould be allowed=%t : %v", Failed, testID, tc.name, tc.allowed, err)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould be allowed=%t", Success, testID, tc.name, tc.allowed)
+	}
+}
+
+func Test_PolicyAdminOnly(t *testing.T) {
+	_, err := auth.NewPolicy(map[string][]string{
+		auth.ActionEmployeeUndelete: {auth.RoleHREditor},
+	})
+	if err == nil {
+		t.Fatalf("\t%s\tTest 1:\tShould NOT grant admin only actions to other roles", Failed)
+	}
+	t.Logf("\t%s\tTest 1:\tShould NOT grant admin only actions to other roles", Success)
+}
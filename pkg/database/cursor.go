@@ -0,0 +1,192 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor was not issued by the service
+// or has been altered.
+var ErrInvalidCursor = errors.New("cursor is not valid")
+
+// cursorKey signs the cursors. It defaults to a random key, which is only
+// good for a single instance; SetCursorSecret shares one across replicas.
+var cursorKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// SetCursorSecret sets the key used to sign cursors. It must be called
+// before serving requests.
+func SetCursorSecret(secret []byte) {
+	cursorKey = secret
+}
+
+// Cursor marks a position in a keyset paginated listing: the value of the
+// sort column and the id of a row. Before selects the rows preceding the
+// position instead of the ones following it.
+type Cursor struct {
+	Sort      string `json:"s"`
+	Direction string `json:"d"`
+	Value     string `json:"v"`
+	ID        string `json:"i"`
+	Before    bool   `json:"b,omitempty"`
+}
+
+// Cursors are the encoded cursors to the pages around the current one.
+// They are empty when there is no such page.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// Encode returns the opaque, signed form of the cursor.
+func (c Cursor) Encode() string {
+	payload, _ := json.Marshal(c)
+
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DecodeCursor verifies the signature of an encoded cursor and decodes it.
+func DecodeCursor(s string) (Cursor, error) {
+	p, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Direction != "asc" && c.Direction != "desc" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if _, err := c.sortValue(); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// sortValue converts the value of the cursor to the type of the sort column.
+func (c Cursor) sortValue() (interface{}, error) {
+	switch c.Sort {
+	case "id":
+		return c.ID, nil
+	case "created_on", "updated_on":
+		return time.Parse(time.RFC3339Nano, c.Value)
+	}
+	return nil, fmt.Errorf("unknown sort column %q", c.Sort)
+}
+
+// CursorValue formats a sort column value the way cursors store it.
+func CursorValue(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// KeysetCondition returns the condition selecting the rows past the cursor
+// and adds its parameters to data. The prefix qualifies the columns, e.g.
+// "e.". It is empty when the pagination has no cursor.
+func KeysetCondition(pagi Pagination, prefix string, data map[string]interface{}) string {
+	if pagi.Cursor == nil {
+		return ""
+	}
+	c := pagi.Cursor
+
+	// Walking back over a descending listing moves up the values.
+	op := "<"
+	if (c.Direction == "asc") != c.Before {
+		op = ">"
+	}
+
+	data["cursor_id"] = c.ID
+	if c.Sort == "id" {
+		return fmt.Sprintf("%sid %s :cursor_id", prefix, op)
+	}
+
+	val, _ := c.sortValue()
+	data["cursor_value"] = val
+
+	return fmt.Sprintf("(%[1]s%[2]s %[3]s :cursor_value or (%[1]s%[2]s = :cursor_value and %[1]sid %[3]s :cursor_id))", prefix, c.Sort, op)
+}
+
+// PageWindow returns the range of the fetched rows making up the page and
+// whether there are more rows past it. Keyset queries fetch one row more
+// than the page size to find out.
+func PageWindow(pagi Pagination, fetched int) (start int, end int, more bool) {
+	if fetched <= pagi.PerPage {
+		return 0, fetched, false
+	}
+
+	// Walking back the extra row ends up first once the rows are put back
+	// in the listing order.
+	if pagi.Cursor != nil && pagi.Cursor.Before {
+		return fetched - pagi.PerPage, fetched, true
+	}
+	return 0, pagi.PerPage, true
+}
+
+// PageCursors builds the cursors to the pages around the current one from
+// its first and last rows, only Value and ID of those are used.
+func PageCursors(pagi Pagination, more bool, first Cursor, last Cursor) Cursors {
+	at := func(c Cursor, before bool) string {
+		c.Sort = pagi.Sort
+		c.Direction = pagi.Direction
+		c.Before = before
+		return c.Encode()
+	}
+
+	var cs Cursors
+	switch {
+	case pagi.Cursor == nil:
+		if more {
+			cs.Next = at(last, false)
+		}
+		if pagi.Page > 0 {
+			cs.Prev = at(first, true)
+		}
+	case pagi.Cursor.Before:
+		cs.Next = at(last, false)
+		if more {
+			cs.Prev = at(first, true)
+		}
+	default:
+		if more {
+			cs.Next = at(last, false)
+		}
+		cs.Prev = at(first, true)
+	}
+
+	return cs
+}
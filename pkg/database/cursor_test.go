@@ -0,0 +1,108 @@
+package database_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+// Success and failure markers.
+const (
+	Success = "\u2713"
+	Failed  = "\u2717"
+)
+
+func Test_Cursor(t *testing.T) {
+	database.SetCursorSecret([]byte("cursor-secret-for-tests"))
+
+	c := database.Cursor{
+		Sort:      "created_on",
+		Direction: "desc",
+		Value:     database.CursorValue(time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)),
+		ID:        "42",
+	}
+	encoded := c.Encode()
+
+	t.Log("Given the need to hand out opaque cursors")
+	{
+		got, err := database.DecodeCursor(encoded)
+		if err != nil || got != c {
+			t.Fatalf("\t%s\tTest 1:\tShould decode the cursor it encoded : %v %v", Failed, got, err)
+		}
+		t.Logf("\t%s\tTest 1:\tShould decode the cursor it encoded", Success)
+
+		payload, sig, _ := strings.Cut(encoded, ".")
+		tampered := database.Cursor{Sort: "created_on", Direction: "desc", Value: c.Value, ID: "1"}.Encode()
+		tamperedPayload, _, _ := strings.Cut(tampered, ".")
+		if _, err := database.DecodeCursor(tamperedPayload + "." + sig); err == nil {
+			t.Fatalf("\t%s\tTest 2:\tShould reject an altered cursor", Failed)
+		}
+		t.Logf("\t%s\tTest 2:\tShould reject an altered cursor", Success)
+
+		database.SetCursorSecret([]byte("another-secret"))
+		if _, err := database.DecodeCursor(payload + "." + sig); err == nil {
+			t.Fatalf("\t%s\tTest 3:\tShould reject a cursor signed with another key", Failed)
+		}
+		t.Logf("\t%s\tTest 3:\tShould reject a cursor signed with another key", Success)
+	}
+}
+
+func Test_KeysetCondition(t *testing.T) {
+	cases := []struct {
+		name      string
+		direction string
+		before    bool
+		want      string
+	}{
+		{name: "next page descending", direction: "desc", want: "e.created_on < :cursor_value"},
+		{name: "previous page descending", direction: "desc", before: true, want: "e.created_on > :cursor_value"},
+		{name: "next page ascending", direction: "asc", want: "e.created_on > :cursor_value"},
+		{name: "previous page ascending", direction: "asc", before: true, want: "e.created_on < :cursor_value"},
+	}
+
+	t.Log("Given the need to select the rows past a cursor")
+	for testID, tc := range cases {
+		pagi := database.NewPagination()
+		pagi.Direction = tc.direction
+		pagi.Cursor = &database.Cursor{
+			Sort:      "created_on",
+			Direction: tc.direction,
+			Value:     "2021-12-01T00:00:00Z",
+			ID:        "42",
+			Before:    tc.before,
+		}
+
+		data := make(map[string]interface{})
+		got := database.KeysetCondition(pagi, "e.", data)
+		if !strings.Contains(got, tc.want) || data["cursor_id"] != "42" {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould get %q, got %q", Failed, testID, tc.name, tc.want, got)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould get %q", Success, testID, tc.name, tc.want)
+	}
+}
+
+func Test_PageWindow(t *testing.T) {
+	pagi := database.NewPagination()
+	pagi.PerPage = 2
+
+	t.Log("Given the need to trim the extra row read to detect more pages")
+	{
+		if start, end, more := database.PageWindow(pagi, 3); start != 0 || end != 2 || !more {
+			t.Fatalf("\t%s\tTest 1:\tShould drop the last row, got %d:%d %t", Failed, start, end, more)
+		}
+		t.Logf("\t%s\tTest 1:\tShould drop the last row", Success)
+
+		pagi.Cursor = &database.Cursor{Before: true}
+		if start, end, more := database.PageWindow(pagi, 3); start != 1 || end != 3 || !more {
+			t.Fatalf("\t%s\tTest 2:\tShould drop the first row walking back, got %d:%d %t", Failed, start, end, more)
+		}
+		t.Logf("\t%s\tTest 2:\tShould drop the first row walking back", Success)
+
+		if start, end, more := database.PageWindow(pagi, 2); start != 0 || end != 2 || more {
+			t.Fatalf("\t%s\tTest 3:\tShould keep a short page, got %d:%d %t", Failed, start, end, more)
+		}
+		t.Logf("\t%s\tTest 3:\tShould keep a short page", Success)
+	}
+}
@@ -3,10 +3,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net/url"
 	"reflect"
 	"regexp"
@@ -17,14 +19,21 @@ import (
 	"cloud.google.com/go/compute/metadata"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/pansachin/employee-service/pkg/api"
+	"github.com/pansachin/employee-service/pkg/metrics"
 )
 
 // Set of error variables for CRUD operations.
 var (
 	ErrDBNotFound        = errors.New("data not found")
 	ErrDBDuplicatedEntry = errors.New("duplicated entry")
+	ErrDBVersionConflict = errors.New("data was modified concurrently")
 )
 
 // Config is the required properties for the db
@@ -142,7 +151,7 @@ func StatusCheck(ctx context.Context, db *sqlx.DB) error {
 	// First check we can ping the database.
 	var pingError error
 	for attempts := 1; ; attempts++ {
-		pingError = db.Ping()
+		pingError = db.PingContext(ctx)
 		if pingError == nil {
 			break
 		}
@@ -170,18 +179,54 @@ func StatusCheck(ctx context.Context, db *sqlx.DB) error {
 
 // Transactor interface needed to begin transaction.
 type Transactor interface {
-	Beginx() (*sqlx.Tx, error)
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
 }
 
-// WithinTran runs passed function and does commit/rollback at the end.
-func WithinTran(ctx context.Context, log *slog.Logger, db Transactor, fn func(sqlx.ExtContext) error) error {
+// Transactions aborted by a lock conflict are run up to tranAttempts times,
+// waiting about tranBackoff before the first retry and twice as long before
+// each following one.
+const (
+	tranAttempts = 5
+	tranBackoff  = 20 * time.Millisecond
+)
+
+// WithinTran runs passed function and does commit/rollback at the end. The
+// options set the isolation level, nil uses the database default. When
+// the database aborts the transaction on a deadlock or a lock wait timeout
+// it is retried with backoff, so fn must be safe to run again.
+func WithinTran(ctx context.Context, log *slog.Logger, db Transactor, opts *sql.TxOptions, fn func(sqlx.ExtContext) error) (err error) {
+	ctx, span := startSpan(ctx, "pkg.database.withintran", "")
+	defer func() { endSpan(span, err) }()
+
 	traceID := api.GetTracerUID(ctx)
 
-	// Begin the transaction.
+	for attempt := 1; ; attempt++ {
+		err = runTran(ctx, log, db, opts, fn)
+		if err == nil || !isLockConflict(err) || attempt == tranAttempts {
+			return err
+		}
+
+		delay := backoff(attempt)
+		log.Info("retry db transaction", "traceid", traceID, "attempt", attempt, "delay", delay, slog.Any("ERROR", err))
+		metrics.Transactions.WithLabelValues("retry").Inc()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("retry db transaction: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runTran runs a single attempt of the transaction.
+func runTran(ctx context.Context, log *slog.Logger, db Transactor, opts *sql.TxOptions, fn func(sqlx.ExtContext) error) error {
+	traceID := api.GetTracerUID(ctx)
+
+	// Begin the transaction, it is rolled back if the context is canceled.
 	log.Info("begin db transaction", "traceid", traceID)
-	tx, err := db.Beginx()
+	tx, err := db.BeginTxx(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("begin db transaction: %w", err)
+		return fmt.Errorf("begin db transaction: %w", Translate(err))
 	}
 
 	// Mark to the defer function a rollback is required.
@@ -193,7 +238,8 @@ func WithinTran(ctx context.Context, log *slog.Logger, db Transactor, fn func(sq
 	defer func() {
 		if mustRollback {
 			log.Info("rollback db transaction", "traceid", traceID)
-			if err := tx.Rollback(); err != nil {
+			metrics.Transactions.WithLabelValues("rollback").Inc()
+			if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 				log.Error("unable to rollback db transaction", "traceid", traceID, slog.Any("ERROR", err))
 			}
 		}
@@ -211,19 +257,39 @@ func WithinTran(ctx context.Context, log *slog.Logger, db Transactor, fn func(sq
 	// Commit the transaction.
 	log.Info("commit db transaction", "traceid", traceID)
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit db transaction: %w", err)
+		return fmt.Errorf("commit db transaction: %w", Translate(err))
 	}
+	metrics.Transactions.WithLabelValues("commit").Inc()
 
 	return nil
 }
 
+// isLockConflict reports whether the transaction failed on a deadlock or a
+// lock wait timeout.
+func isLockConflict(err error) bool {
+	return errors.Is(Translate(err), ErrDBLockConflict)
+}
+
+// backoff is the delay before the retry following the attempt. Half of it
+// is random so conflicting transactions do not retry in lockstep.
+func backoff(attempt int) time.Duration {
+	d := tranBackoff << (attempt - 1)
+	return d/2 + rand.N(d/2)
+}
+
 // -----------------------------------------------------------------------
 // Query Helpers
 // -----------------------------------------------------------------------
 
 // NamedExecContext is a helper function to execute a CUD operation with
 // logging and tracing.
-func NamedExecContext(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}) (DBResults, error) {
+func NamedExecContext(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}) (_ DBResults, err error) {
+	ctx, span := startSpan(ctx, "pkg.database.namedexeccontext", query)
+	defer func() {
+		err = Translate(err)
+		endSpan(span, err)
+	}()
+
 	q := queryString(query, data)
 	traceID := api.GetTracerUID(ctx)
 	log.Debug("database.NamedExecContext", "traceid", traceID, "query", q)
@@ -255,7 +321,13 @@ func NamedExecContext(ctx context.Context, log *slog.Logger, db sqlx.ExtContext,
 
 // NamedQuerySlice is a helper function for executing queries that return a
 // collection of data to be unmarshalled into a slice.
-func NamedQuerySlice(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}, dest interface{}) error {
+func NamedQuerySlice(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}, dest interface{}) (err error) {
+	ctx, span := startSpan(ctx, "pkg.database.namedqueryslice", query)
+	defer func() {
+		err = Translate(err)
+		endSpan(span, err)
+	}()
+
 	q := queryString(query, data)
 	traceID := api.GetTracerUID(ctx)
 	log.Debug("database.NamedQuerySlice", "traceid", traceID, "query", q)
@@ -282,9 +354,54 @@ func NamedQuerySlice(ctx context.Context, log *slog.Logger, db sqlx.ExtContext,
 	return nil
 }
 
+// NamedQueryEach is a helper function for executing queries whose rows are
+// handled one at a time, so that large results are never held in memory.
+// Every row is unmarshalled into dest, a pointer to a struct, before fn is
+// called. Iteration stops at the first error returned by fn.
+func NamedQueryEach(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}, dest interface{}, fn func() error) (err error) {
+	ctx, span := startSpan(ctx, "pkg.database.namedqueryeach", query)
+	defer func() {
+		err = Translate(err)
+		endSpan(span, err)
+	}()
+
+	q := queryString(query, data)
+	traceID := api.GetTracerUID(ctx)
+	log.Debug("database.NamedQueryEach", "traceid", traceID, "query", q)
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return errors.New("must provide a pointer to a struct")
+	}
+
+	rows, err := sqlx.NamedQueryContext(ctx, db, query, data)
+	if err != nil {
+		return err
+	}
+	defer rows.Close() //nolint:all
+
+	zero := reflect.Zero(val.Elem().Type())
+	for rows.Next() {
+		val.Elem().Set(zero)
+		if err := rows.StructScan(dest); err != nil && !strings.Contains(err.Error(), "unsupported Scan, storing driver.Value type <nil> into type *json.RawMessage") {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // QueryxContextSlice is a helper function for executing queries that return a
 // collection of data to be unmarshalled into a slice.
-func QueryxContextSlice(ctx context.Context, log *slog.Logger, db sqlx.QueryerContext, query string, args []interface{}, dest interface{}) error {
+func QueryxContextSlice(ctx context.Context, log *slog.Logger, db sqlx.QueryerContext, query string, args []interface{}, dest interface{}) (err error) {
+	ctx, span := startSpan(ctx, "pkg.database.queryxcontextslice", query)
+	defer func() {
+		err = Translate(err)
+		endSpan(span, err)
+	}()
+
 	traceID := api.GetTracerUID(ctx)
 	log.Debug("database.QueryxContextSlice", "traceid", traceID, "query", query, "args", args)
 	val := reflect.ValueOf(dest)
@@ -312,7 +429,13 @@ func QueryxContextSlice(ctx context.Context, log *slog.Logger, db sqlx.QueryerCo
 
 // NamedQueryStruct is a helper function for executing queries that return a
 // single value to be unmarshalled into a struct type.
-func NamedQueryStruct(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}, dest interface{}) error {
+func NamedQueryStruct(ctx context.Context, log *slog.Logger, db sqlx.ExtContext, query string, data interface{}, dest interface{}) (err error) {
+	ctx, span := startSpan(ctx, "pkg.database.namedquerystruct", query)
+	defer func() {
+		err = Translate(err)
+		endSpan(span, err)
+	}()
+
 	traceID := api.GetTracerUID(ctx)
 	log.Debug("database.NamedQuerySlice", "traceid", traceID, "query", query, "args", data)
 
@@ -333,6 +456,30 @@ func NamedQueryStruct(ctx context.Context, log *slog.Logger, db sqlx.ExtContext,
 	return nil
 }
 
+// startSpan starts a client span for a database operation. The statement is
+// recorded as written, named parameters are never replaced by their values.
+func startSpan(ctx context.Context, name string, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{semconv.DBSystemMySQL}
+	if query != "" {
+		attrs = append(attrs, semconv.DBStatement(strings.TrimSpace(query)))
+	}
+
+	return otel.GetTracerProvider().Tracer("").Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records the error, if any, and ends the span. Not found results
+// are expected and do not mark the span as failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrDBNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // queryString provides a pretty print version of the query and parameters.
 func queryString(query string, args ...interface{}) string {
 	if args[0] == nil {
@@ -1,6 +1,44 @@
 package database
 
-import "errors"
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Set of error variables for the database failures translated from the
+// MySQL errors.
+var (
+	ErrDBReferenceNotFound = errors.New("referenced data not found")
+	ErrDBStillReferenced   = errors.New("data is still referenced")
+	ErrDBDataTooLong       = errors.New("value is too long")
+	ErrDBLockConflict      = errors.New("data is locked by a concurrent change, try again")
+	ErrDBUnavailable       = errors.New("database unavailable, try again")
+)
+
+// MySQL error numbers translated into Error values.
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlTooManyConnections = 1040
+	mysqlServerShutdown     = 1053
+	mysqlDuplicateEntry     = 1062
+	mysqlLockWaitTimeout    = 1205
+	mysqlDeadlock           = 1213
+	mysqlDataTooLong        = 1406
+	mysqlRowIsReferenced    = 1451
+	mysqlNoReferencedRow    = 1452
+)
+
+// Patterns extracting the field from the MySQL error messages.
+var (
+	duplicateKeyPattern = regexp.MustCompile("for key '(?:[^'.]+\\.)?([^']+)'")
+	foreignKeyPattern   = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+	columnPattern       = regexp.MustCompile("for column '([^']+)'")
+)
 
 // ErrorResponse is the form used for Database responses from failures in the DB
 type ErrorResponse struct {
@@ -9,10 +47,15 @@ type ErrorResponse struct {
 }
 
 // Error is used to pass an error during the request through the
-// application with database specific context.
+// application with database specific context. Field names the field of
+// the request the error is about, when it is known.
 type Error struct {
 	Err    error
 	Status int
+	Field  string
+
+	// cause is the driver error the Error was translated from.
+	cause error
 }
 
 // NewError wraps a provided error with an HTTP status code. This
@@ -30,6 +73,15 @@ func (re *Error) Error() string {
 	return re.Err.Error()
 }
 
+// Unwrap gives access to the wrapped error and to the driver error it was
+// translated from.
+func (re *Error) Unwrap() []error {
+	if re.cause == nil {
+		return []error{re.Err}
+	}
+	return []error{re.Err, re.cause}
+}
+
 // IsError checks if the error type Error Exists
 func IsError(err error) bool {
 	var re *Error
@@ -44,3 +96,66 @@ func GetError(err error) *Error {
 	}
 	return re
 }
+
+// Translate converts the MySQL errors the application can explain into
+// Error values carrying the HTTP status and, when the message names it,
+// the field at fault. Other errors are returned unchanged.
+func Translate(err error) error {
+	if err == nil || IsError(err) {
+		return err
+	}
+
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) {
+		return &Error{Err: ErrDBUnavailable, Status: http.StatusServiceUnavailable, cause: err}
+	}
+
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Number {
+	case mysqlDuplicateEntry:
+		return &Error{Err: ErrDBDuplicatedEntry, Status: http.StatusConflict, Field: keyField(myErr.Message), cause: err}
+
+	case mysqlNoReferencedRow:
+		return &Error{Err: ErrDBReferenceNotFound, Status: http.StatusBadRequest, Field: match(foreignKeyPattern, myErr.Message), cause: err}
+
+	case mysqlRowIsReferenced:
+		return &Error{Err: ErrDBStillReferenced, Status: http.StatusConflict, cause: err}
+
+	case mysqlDataTooLong:
+		return &Error{Err: ErrDBDataTooLong, Status: http.StatusBadRequest, Field: match(columnPattern, myErr.Message), cause: err}
+
+	case mysqlDeadlock, mysqlLockWaitTimeout:
+		return &Error{Err: ErrDBLockConflict, Status: http.StatusServiceUnavailable, cause: err}
+
+	case mysqlTooManyConnections, mysqlServerShutdown:
+		return &Error{Err: ErrDBUnavailable, Status: http.StatusServiceUnavailable, cause: err}
+	}
+
+	return err
+}
+
+// keyField derives the field from the name of a unique key, named
+// uk_<table>_<column> by the migrations.
+func keyField(msg string) string {
+	key := match(duplicateKeyPattern, msg)
+	if !strings.HasPrefix(key, "uk_") {
+		return ""
+	}
+	_, column, ok := strings.Cut(strings.TrimPrefix(key, "uk_"), "_")
+	if !ok {
+		return ""
+	}
+	return column
+}
+
+// match returns the first group matched by the pattern in the message.
+func match(pattern *regexp.Regexp, msg string) string {
+	m := pattern.FindStringSubmatch(msg)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
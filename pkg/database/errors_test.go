@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+func Test_Translate(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantErr    error
+		wantStatus int
+		wantField  string
+	}{
+		{
+			name:       "duplicate entry",
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Sales' for key 'department.uk_department_name'"},
+			wantErr:    database.ErrDBDuplicatedEntry,
+			wantStatus: http.StatusConflict,
+			wantField:  "name",
+		},
+		{
+			name:       "missing reference",
+			err:        &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`employee_db`.`employee`, CONSTRAINT `fk_employee_manager` FOREIGN KEY (`manager_id`) REFERENCES `employee` (`id`))"},
+			wantErr:    database.ErrDBReferenceNotFound,
+			wantStatus: http.StatusBadRequest,
+			wantField:  "manager_id",
+		},
+		{
+			name:       "still referenced",
+			err:        &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails"},
+			wantErr:    database.ErrDBStillReferenced,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "data too long",
+			err:        fmt.Errorf("inserting employee: %w", &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'name' at row 1"}),
+			wantErr:    database.ErrDBDataTooLong,
+			wantStatus: http.StatusBadRequest,
+			wantField:  "name",
+		},
+		{
+			name:       "deadlock",
+			err:        &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"},
+			wantErr:    database.ErrDBLockConflict,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "connection lost",
+			err:        mysql.ErrInvalidConn,
+			wantErr:    database.ErrDBUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	t.Log("Given the need to explain database failures to the client")
+	for testID, tc := range cases {
+		err := database.Translate(tc.err)
+
+		dbErr := database.GetError(err)
+		if dbErr == nil || !errors.Is(err, tc.wantErr) {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould translate to %q, got %v", Failed, testID, tc.name, tc.wantErr, err)
+		}
+		if dbErr.Status != tc.wantStatus || dbErr.Field != tc.wantField {
+			t.Fatalf("\t%s\tTest %d [%s]:\tShould get %d on %q, got %d on %q", Failed, testID, tc.name, tc.wantStatus, tc.wantField, dbErr.Status, dbErr.Field)
+		}
+		t.Logf("\t%s\tTest %d [%s]:\tShould translate to %q", Success, testID, tc.name, tc.wantErr)
+	}
+
+	other := errors.New("something else")
+	if err := database.Translate(other); err != other {
+		t.Fatalf("\t%s\tTest %d:\tShould leave other errors unchanged, got %v", Failed, len(cases), err)
+	}
+	t.Logf("\t%s\tTest %d:\tShould leave other errors unchanged", Success, len(cases))
+
+	var myErr *mysql.MySQLError
+	if !errors.As(database.Translate(cases[0].err), &myErr) {
+		t.Fatalf("\t%s\tTest %d:\tShould keep the driver error", Failed, len(cases)+1)
+	}
+	t.Logf("\t%s\tTest %d:\tShould keep the driver error", Success, len(cases)+1)
+}
@@ -52,49 +52,54 @@ type Pagination struct {
 	//   - `asc` - Ascending, from A to Z
 	//   - `desc` - Descending, from Z to A
 	Direction string `db:"direction" json:"direction"`
+	// Opaque cursor returned as next_cursor or prev_cursor by a previous
+	// call, it replaces page, sort and direction
+	//
+	// in: query
+	// required: false
+	// type: string
+	Cursor *Cursor `db:"-" json:"cursor,omitempty"`
 }
 
-// PaginationResults Pagination details
-// swagger:parameters sampleResultsQuery
+// PaginationResults describes the page of a listing returned to the client.
+// swagger:model PaginationResults
 type PaginationResults struct {
-	// The current page
+	// The number of records matching the query
 	//
-	// in: query
-	// type: integer
-	// enum: 1
-	// minimum: 1
-	// required: false
-	Page int `db:"page" json:"page"`
+	// example: 240
+	Total int `json:"total"`
+	// The current page, omitted when paging with a cursor
+	//
+	// example: 3
+	Page int `json:"page,omitempty"`
 	// The per page limit
 	//
-	// in: query
-	// type: integer
-	// enum: 20
-	// minimum: 1
-	// maximum: 100
-	// required: false
-	PerPage int `db:"per_page" json:"per_page"`
-	// The column to sort on
+	// example: 20
+	PerPage int `json:"per_page"`
+	// The column sorted on
 	//
-	// in: query
-	// required: false
-	// type: string
-	// enum: created
-	// description:
-	//   Sort order:
-	//   - `created` - When the record was created in the database
-	Sort string `db:"sort" json:"sort"`
+	// enum: created,updated,id
+	Sort string `json:"sort"`
 	// The direction of the sort
 	//
-	// in: query
-	// required: false
-	// type: string
 	// enum: asc,desc
-	// description:
-	//   Sort order:
-	//   - `asc` - Ascending, from A to Z
-	//   - `desc` - Descending, from Z to A
-	Direction string `db:"direction" json:"direction"`
+	Direction string `json:"direction"`
+}
+
+// NewPaginationResults describes the page selected by pagi out of total
+// records, using the names the query parameters are given in.
+func NewPaginationResults(pagi Pagination, total int) PaginationResults {
+	res := PaginationResults{
+		Total:     total,
+		PerPage:   pagi.PerPage,
+		Sort:      strings.TrimSuffix(pagi.Sort, "_on"),
+		Direction: pagi.Direction,
+	}
+	if pagi.Cursor == nil && pagi.PerPage > 0 {
+		res.Page = pagi.Page/pagi.PerPage + 1
+	}
+
+	return res
 }
 
 // NewPagination to initialize the pagination
@@ -162,6 +167,17 @@ func PaginationParams(r *http.Request) (Pagination, error) {
 		}
 	}
 
+	if val, ok := qparams["cursor"]; ok {
+		cursor, err := DecodeCursor(val[0])
+		if err != nil {
+			return Pagination{}, api.NewRequestError(err, http.StatusBadRequest)
+		}
+		pagi.Cursor = &cursor
+		pagi.Page = 0
+		pagi.Sort = cursor.Sort
+		pagi.Direction = cursor.Direction
+	}
+
 	return pagi, nil
 }
 
@@ -172,8 +188,19 @@ func PaginationParams(r *http.Request) (Pagination, error) {
 // data values should appear, not for SQL keywords, identifiers etc. You
 // cannot use it to dynamically specify the ORDER BY OR GROUP BY values.
 // https://stackoverflow.com/questions/30867337/golang-order-by-issue-with-mysql
+//
+// Walking back from a cursor the rows are read in the reverse order, they
+// must be reversed again once fetched, see PageWindow.
 func PaginationQuery(pagi Pagination, q string) string {
+	direction := pagi.Direction
+	if pagi.Cursor != nil && pagi.Cursor.Before {
+		direction = "asc"
+		if pagi.Direction == "asc" {
+			direction = "desc"
+		}
+	}
+
 	q = strings.ReplaceAll(q, ":sort", pagi.Sort)
-	q = strings.ReplaceAll(q, ":direction", pagi.Direction)
+	q = strings.ReplaceAll(q, ":direction", direction)
 	return q
 }
@@ -0,0 +1,29 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/pansachin/employee-service/pkg/database"
+)
+
+func Test_NewPaginationResults(t *testing.T) {
+	pagi := database.NewPagination()
+	pagi.PerPage = 10
+	pagi.Page = 20
+	pagi.Sort = "updated_on"
+
+	t.Log("Given the need to describe a page of a listing")
+	{
+		want := database.PaginationResults{Total: 42, Page: 3, PerPage: 10, Sort: "updated", Direction: "desc"}
+		if got := database.NewPaginationResults(pagi, 42); got != want {
+			t.Fatalf("\t%s\tTest 1:\tShould describe the third page, got %+v", Failed, got)
+		}
+		t.Logf("\t%s\tTest 1:\tShould describe the third page", Success)
+
+		pagi.Cursor = &database.Cursor{Sort: "updated_on", Direction: "desc"}
+		if got := database.NewPaginationResults(pagi, 42); got.Page != 0 || got.Total != 42 {
+			t.Fatalf("\t%s\tTest 2:\tShould leave out the page number with a cursor, got %+v", Failed, got)
+		}
+		t.Logf("\t%s\tTest 2:\tShould leave out the page number with a cursor", Success)
+	}
+}
@@ -0,0 +1,84 @@
+// Package metrics for prometheus metrics collected by the service
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exposed by the service.
+const namespace = "employee_service"
+
+// registry holds every collector exposed on the metrics endpoint. A
+// dedicated registry keeps metrics registered by dependencies out of it.
+var registry = prometheus.NewRegistry()
+
+// Set of collectors for the service.
+var (
+	// Requests counts the handled requests by method, route template and
+	// status code.
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// RequestDuration observes the request latency by method, route
+	// template and status code.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of handled HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Errors counts the requests that ended with an error.
+	Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_errors_total",
+		Help:      "Number of HTTP requests that ended with an error.",
+	}, []string{"method", "route"})
+
+	// Panics counts the requests that panicked.
+	Panics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "Number of HTTP requests that panicked.",
+	}, []string{"method", "route"})
+
+	// Transactions counts the database transactions by result, commit,
+	// rollback or retry after a lock conflict.
+	Transactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transactions_total",
+		Help:      "Number of database transactions by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Requests,
+		RequestDuration,
+		Errors,
+		Panics,
+		Transactions,
+	)
+}
+
+// RegisterDB exposes the connection pool statistics of the given database
+// as gauges labelled with the database name.
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler returns the http handler serving the metrics in the prometheus
+// text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,110 @@
+// Package tracer for configuring opentelemetry tracing
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// Supported span exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config holds the tracing configuration.
+type Config struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+
+	// ServiceVersion is reported as the service.version resource attribute.
+	ServiceVersion string
+
+	// Exporter selects where spans are sent: none, stdout or otlp.
+	// With none, spans are still created so trace ids are propagated and
+	// logged, they are just not exported anywhere.
+	Exporter string
+
+	// Endpoint is the host:port of the OTLP/HTTP collector.
+	Endpoint string
+
+	// Insecure disables TLS when talking to the OTLP collector.
+	Insecure bool
+
+	// Probability is the fraction of new traces sampled, between 0 and 1.
+	// Traces started upstream follow the sampling decision of the parent.
+	Probability float64
+}
+
+// Init configures the global tracer provider and the W3C trace context
+// propagator. The returned function flushes and stops the provider and
+// must be called on shutdown.
+func Init(ctx context.Context, log *slog.Logger, cfg Config) (func(context.Context) error, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Probability))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	log.Info("startup.tracer", "status", "tracer initialized", "exporter", cfg.Exporter, "endpoint", cfg.Endpoint, "probability", cfg.Probability)
+
+	return tp.Shutdown, nil
+}
+
+// newExporter returns the span exporter selected in the configuration. A
+// nil exporter means spans are not exported.
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return nil, nil
+
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.Endpoint),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+}